@@ -10,6 +10,32 @@ import (
 
 type CommonConfig struct {
 	JWTSecret string
+	// VerificationSecret signs email verification codes and MFA
+	// challenges; it is required
+	VerificationSecret string
+	// RequireVerifiedEmail blocks login until the email is verified
+	RequireVerifiedEmail bool
+	// TOTPIssuer names this service in authenticator apps
+	TOTPIssuer string
+	// TOTPEncryptionKey encrypts TOTP secrets at rest; it is required and
+	// must never change once secrets are stored
+	TOTPEncryptionKey string
+	// SigningKeyID selects the key of Keys that signs new tokens; the other
+	// keys are only used to verify tokens during a rotation
+	SigningKeyID string
+	Keys         []KeyConfig
+	// AdminEmails are users granted the admin role at startup, so the
+	// first admin can be bootstrapped; the email must be verified and
+	// belong to a single account
+	AdminEmails []string
+}
+
+// KeyConfig is a RS256/ES256 JWT key loaded from PEM files. Keys without a
+// private key file can only verify tokens.
+type KeyConfig struct {
+	ID             string
+	PrivateKeyFile string
+	PublicKeyFile  string
 }
 
 type GatewayConfig struct {
@@ -38,6 +64,13 @@ type Config struct {
 	Logger   LoggerConfig
 	Jaeger   JaegerConfig
 	Metrics  MetricsConfig
+	Mail     MailConfig
+	Lockout  LockoutConfig
+	Password PasswordConfig
+	Account  AccountConfig
+	Blob     BlobConfig
+	Avatar   AvatarConfig
+	Suggest  SuggestConfig
 }
 
 // Server config struct
@@ -58,6 +91,9 @@ type ServerConfig struct {
 	Timeout           time.Duration
 	MaxConnectionAge  time.Duration
 	Time              time.Duration
+	// TrustedProxies are the addresses of gateways whose X-Forwarded-For
+	// header is trusted for the client IP
+	TrustedProxies []string
 }
 
 // Metrics config
@@ -88,6 +124,115 @@ type RedisConfig struct {
 	DB             int
 }
 
+// Mail config
+type MailConfig struct {
+	// Driver is either log or file and is required; the log driver
+	// redacts the tokens in links, use file to follow them
+	Driver string
+	From   string
+	// Dir is where the file driver writes emails
+	Dir string
+	// ResetURL is the page the password reset link points to
+	ResetURL string
+	// VerifyURL is the page the email verification link points to
+	VerifyURL string
+}
+
+// Lockout config for failed logins
+type LockoutConfig struct {
+	// Store is redis or memory; redis falls back to memory when it is
+	// not reachable at startup
+	Store string
+	// MaxAttempts is the number of failures before an account is locked
+	MaxAttempts int
+	// IPMaxAttempts is the number of failures before a client IP is locked
+	IPMaxAttempts int
+	// Window in minutes in which failures are counted
+	Window time.Duration
+	// LockDuration in minutes
+	LockDuration time.Duration
+	// BaseDelay in milliseconds, doubled for every further failure
+	BaseDelay time.Duration
+	// MaxDelay in seconds
+	MaxDelay time.Duration
+}
+
+// Password policy config
+type PasswordConfig struct {
+	MinLength     int
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// DisallowUserInfo rejects passwords containing the username or email
+	DisallowUserInfo bool
+	// BreachedListFile has one hex SHA-1 hash or hash prefix of a breached
+	// password per line
+	BreachedListFile string
+	Hash             HashConfig
+}
+
+// Password hashing config. Passwords hashed with another algorithm or with
+// weaker parameters are rehashed on the next login.
+type HashConfig struct {
+	// Algorithm is bcrypt or argon2id
+	Algorithm  string
+	BcryptCost int
+	// Argon2Memory in KiB
+	Argon2Memory      uint32
+	Argon2Iterations  uint32
+	Argon2Parallelism uint8
+	Argon2SaltLength  uint32
+	Argon2KeyLength   uint32
+	// ImportPepper is the pepper of imported salted SHA-512 hashes
+	ImportPepper string
+}
+
+// Account lifecycle config
+type AccountConfig struct {
+	// SweepInterval in seconds between runs of the job that reactivates
+	// accounts whose timed suspension expired and purges deleted accounts
+	SweepInterval time.Duration
+	// DeletionGracePeriod in hours during which a deleted account is
+	// restored by logging in
+	DeletionGracePeriod time.Duration
+	// PurgeMode is delete to remove purged users or anonymize to keep
+	// their rows without personal data
+	PurgeMode string
+}
+
+// Blob storage config
+type BlobConfig struct {
+	// Driver is local
+	Driver string
+	// Dir is where the local driver stores blobs
+	Dir string
+	// BaseURL is the public URL of Dir
+	BaseURL string
+}
+
+// Avatar upload config
+type AvatarConfig struct {
+	// MaxSize in KiB of an uploaded image
+	MaxSize int64
+	// MaxDimension in pixels of the width and height of an uploaded image
+	MaxDimension int
+	// Sizes in pixels of the square images stored for every avatar; the
+	// URL of the largest is written to User.Image
+	Sizes []int
+}
+
+// Follow suggestions config
+type SuggestConfig struct {
+	// CacheTTL in seconds of the suggestions computed for a user, 15
+	// minutes when unset
+	CacheTTL time.Duration
+	// MaxResults is the number of suggestions computed and cached for a
+	// user
+	MaxResults int
+}
+
 // Jaeger
 type JaegerConfig struct {
 	Host        string
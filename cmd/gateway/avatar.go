@@ -0,0 +1,88 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/rezaAmiri123/service-user/gen/pb"
+)
+
+// avatarFormField is the multipart form field holding the image
+const avatarFormField = "avatar"
+
+// avatarChunkSize keeps upload messages well below the gRPC message limit
+const avatarChunkSize = 64 * 1024
+
+// multipartOverhead is allowed on top of the image for the multipart
+// boundaries and headers
+const multipartOverhead = 64 * 1024
+
+// uploadAvatar serves post /user/avatar, a multipart form upload, by
+// streaming the image to UploadAvatar. Bodies larger than maxSize bytes plus
+// the multipart overhead are rejected before they are buffered.
+func uploadAvatar(mux *runtime.ServeMux, client pb.UsersClient, maxSize int64) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		_, outbound := runtime.MarshalerForRequest(mux, r)
+		ctx, err := runtime.AnnotateContext(r.Context(), mux, r, "/user.Users/UploadAvatar")
+		if err != nil {
+			runtime.HTTPError(ctx, mux, outbound, w, r, err)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxSize+multipartOverhead)
+		file, _, err := r.FormFile(avatarFormField)
+		if err != nil {
+			err = status.Errorf(codes.InvalidArgument, "form field %q: %v", avatarFormField, err)
+			runtime.HTTPError(ctx, mux, outbound, w, r, err)
+			return
+		}
+		defer file.Close()
+
+		stream, err := client.UploadAvatar(ctx)
+		if err != nil {
+			runtime.HTTPError(ctx, mux, outbound, w, r, err)
+			return
+		}
+		buf := make([]byte, avatarChunkSize)
+		for {
+			n, err := file.Read(buf)
+			if n > 0 {
+				if err := stream.Send(&pb.AvatarChunk{Data: buf[:n]}); err != nil {
+					// the server ended the call, its status is returned
+					// by CloseAndRecv
+					break
+				}
+			}
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				runtime.HTTPError(ctx, mux, outbound, w, r, status.Error(codes.InvalidArgument, err.Error()))
+				return
+			}
+		}
+		resp, err := stream.CloseAndRecv()
+		if err != nil {
+			runtime.HTTPError(ctx, mux, outbound, w, r, err)
+			return
+		}
+		runtime.ForwardResponseMessage(ctx, mux, outbound, w, r, resp)
+	}
+}
+
+// serveMedia serves the blobs of the local blob store in dir
+func serveMedia(dir string) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		user, file := pathParams["user"], pathParams["file"]
+		if strings.Contains(user, "..") || strings.Contains(file, "..") {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, filepath.Join(dir, "avatars", filepath.Base(user), filepath.Base(file)))
+	}
+}
@@ -35,13 +35,32 @@ func run(cfg *config.Config) error {
 				grpc_opentracing.WithTracer(opentracing.GlobalTracer()),
 			),
 		),
+		grpc.WithStreamInterceptor(
+			grpc_opentracing.StreamClientInterceptor(
+				grpc_opentracing.WithTracer(opentracing.GlobalTracer()),
+			),
+		),
 	}
 
 	err := pb.RegisterUsersHandlerFromEndpoint(context.Background(), mux, cfg.Gateway.GetServerAddress(), opts)
 	if err != nil {
 		return err
 	}
+	err = pb.RegisterUserAdminHandlerFromEndpoint(context.Background(), mux, cfg.Gateway.GetServerAddress(), opts)
+	if err != nil {
+		return err
+	}
+	conn, err := grpc.Dial(cfg.Gateway.GetServerAddress(), opts...)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	mux.HandlePath("POST", "/user/avatar", uploadAvatar(mux, pb.NewUsersClient(conn), cfg.Avatar.MaxSize*1024))
+	if cfg.Blob.Driver == "" || cfg.Blob.Driver == "local" {
+		mux.HandlePath("GET", "/media/avatars/{user}/{file}", serveMedia(cfg.Blob.Dir))
+	}
 	mux.HandlePath("GET", "/swagger.json", serveSwagger)
+	mux.HandlePath("GET", "/admin/swagger.json", serveAdminSwagger)
 
 	mux.HandlePath("GET", "/swagger-ui", serveSwaggerFiles)
 	logrus.Printf("starting gateway server on port %v", cfg.Gateway.Port)
@@ -66,6 +85,10 @@ func serveSwagger(w http.ResponseWriter, r *http.Request, pathParams map[string]
 	http.ServeFile(w, r, "gen/swagger/user.swagger.json")
 }
 
+func serveAdminSwagger(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+	http.ServeFile(w, r, "gen/swagger/admin.swagger.json")
+}
+
 func serveSwaggerFiles(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
 	// TODO this function dosen't work
 	fs := http.FileServer(http.Dir("templates/swagger-ui"))
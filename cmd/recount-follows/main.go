@@ -0,0 +1,43 @@
+// Command recount-follows repairs the follower and following counters of
+// users by recomputing them from the follows table. Run it once after the
+// counters were added and whenever they are suspected to have drifted.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/rezaAmiri123/service-user/cmd/config"
+	"github.com/rezaAmiri123/service-user/internal/model"
+	"github.com/rezaAmiri123/service-user/internal/repository"
+	"github.com/rezaAmiri123/service-user/pkg/logger"
+	"github.com/rezaAmiri123/service-user/pkg/mysql"
+	"github.com/rezaAmiri123/service-user/pkg/redis"
+	"github.com/rezaAmiri123/service-user/pkg/utils"
+)
+
+func main() {
+	configPath := utils.GetConfigPath(os.Getenv("config"))
+	cfg, err := config.GetConfig(configPath)
+	if err != nil {
+		log.Fatalf("Loading config: %v", err)
+	}
+
+	appLogger := logger.NewAPILogger(cfg)
+	appLogger.InitLogger()
+
+	db := mysql.NewGormDB(cfg)
+	defer db.Close()
+	model.AutoMigrate(db)
+
+	redisClient := redis.NewRedisClient(cfg)
+	userRedis := repository.NewUserRedisRepo(redisClient, "user_", appLogger)
+	repo := repository.NewORMUserRepository(db, userRedis)
+
+	n, err := repo.RecountFollows(context.Background())
+	if err != nil {
+		appLogger.Fatalf("recount follows: %v", err)
+	}
+	appLogger.Infof("repaired follow counts of %d users", n)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net"
 	"os"
@@ -15,12 +16,20 @@ import (
 
 	"github.com/rezaAmiri123/service-user/cmd/config"
 	pb "github.com/rezaAmiri123/service-user/gen/pb"
+	"github.com/rezaAmiri123/service-user/internal/auth"
+	"github.com/rezaAmiri123/service-user/internal/avatar"
+	"github.com/rezaAmiri123/service-user/internal/export"
 	"github.com/rezaAmiri123/service-user/internal/handler"
 	"github.com/rezaAmiri123/service-user/internal/interceptors"
+	"github.com/rezaAmiri123/service-user/internal/lockout"
 	"github.com/rezaAmiri123/service-user/internal/model"
+	"github.com/rezaAmiri123/service-user/internal/password"
 	"github.com/rezaAmiri123/service-user/internal/repository"
+	"github.com/rezaAmiri123/service-user/internal/suggest"
+	"github.com/rezaAmiri123/service-user/pkg/blob"
 	"github.com/rezaAmiri123/service-user/pkg/jaeger"
 	"github.com/rezaAmiri123/service-user/pkg/logger"
+	"github.com/rezaAmiri123/service-user/pkg/mail"
 	"github.com/rezaAmiri123/service-user/pkg/metric"
 	"github.com/rezaAmiri123/service-user/pkg/mysql"
 	"github.com/rezaAmiri123/service-user/pkg/redis"
@@ -47,12 +56,31 @@ func main() {
 	)
 	appLogger.Infof("Success parsed config: %#v", cfg.Server.AppVersion)
 
+	auth.SetJWTSecret(cfg.Common.JWTSecret)
+	// neither is derived from the JWT secret: rotating it must not
+	// invalidate verification codes or make TOTP secrets undecryptable
+	if cfg.Common.VerificationSecret == "" {
+		appLogger.Fatalf("Common.VerificationSecret is required")
+	}
+	if cfg.Common.TOTPEncryptionKey == "" {
+		appLogger.Fatalf("Common.TOTPEncryptionKey is required")
+	}
+	auth.SetVerificationSecret(cfg.Common.VerificationSecret)
+	auth.SetTOTPEncryptionKey(cfg.Common.TOTPEncryptionKey)
+	keyManager, err := auth.LoadKeyManager(cfg.Common)
+	if err != nil {
+		appLogger.Fatalf("cannot load jwt keys: %v", err)
+	}
+	auth.SetKeyManager(keyManager)
+
 	db := mysql.NewGormDB(cfg)
 	defer db.Close()
 	model.AutoMigrate(db)
 
 	redisClient := redis.NewRedisClient(cfg)
 	userRedis := repository.NewUserRedisRepo(redisClient, "user_", appLogger)
+	revocations := repository.NewRevocationRedisRepo(redisClient, "revoked_token_")
+	auth.SetRevocationList(revocations)
 
 	metrics, err := metric.CreateMetrics(cfg.Metrics.URL, cfg.Metrics.ServiceName)
 	if err != nil {
@@ -75,8 +103,61 @@ func main() {
 	defer closer.Close()
 	appLogger.Info("Opentracing connected")
 
+	store, err := blob.NewStore(cfg)
+	if err != nil {
+		appLogger.Fatalf("cannot create blob store: %v", err)
+	}
+	avatars := avatar.NewService(store, cfg.Avatar)
+
 	repo := repository.NewORMUserRepository(db, userRedis)
-	h := handler.NewUserHandler(repo, appLogger)
+	auth.SetAccountStore(repo)
+	tokenRepo := repository.NewORMTokenRepository(db)
+	sessions := repository.NewORMSessionRepository(db, repository.NewSessionRedisRepo(redisClient, "session_"))
+	auth.SetSessionStore(sessions)
+	go sweepAccounts(repo, sessions, avatars, cfg.Account, appLogger)
+	roles := repository.NewORMRoleRepository(db)
+	blocks := repository.NewORMBlockRepository(db, userRedis)
+	followRequests := repository.NewORMFollowRequestRepository(db, userRedis)
+	suggestions := suggest.NewService(
+		repository.NewORMSuggestionRepository(db),
+		repo,
+		repository.NewSuggestionRedisRepo(redisClient, "suggestions_"),
+		cfg.Suggest,
+		appLogger,
+	)
+	if err := roles.EnsureRoles(context.Background(), auth.DefaultRoles); err != nil {
+		appLogger.Fatalf("cannot create default roles: %v", err)
+	}
+	bootstrapAdmins(repo, roles, cfg.Common.AdminEmails, appLogger)
+	mailer, err := mail.NewSender(cfg, appLogger)
+	if err != nil {
+		appLogger.Fatalf("cannot create mail sender: %v", err)
+	}
+	var attempts repository.AttemptRepository
+	if cfg.Lockout.Store == "memory" {
+		attempts = repository.NewInMemoryAttemptRepo()
+	} else if err := redisClient.Ping(context.Background()).Err(); err != nil {
+		appLogger.Warnf("redis unavailable, counting login failures in memory: %v", err)
+		attempts = repository.NewInMemoryAttemptRepo()
+	} else {
+		attempts = repository.NewAttemptRedisRepo(redisClient, "login_")
+	}
+	guard := lockout.NewGuard(attempts, cfg.Lockout, appLogger)
+
+	hasher, err := password.NewHasher(cfg.Password.Hash)
+	if err != nil {
+		appLogger.Fatalf("cannot create password hasher: %v", err)
+	}
+	password.SetHasher(hasher)
+	passwordPolicy, err := password.NewPolicy(cfg.Password)
+	if err != nil {
+		appLogger.Fatalf("cannot create password policy: %v", err)
+	}
+
+	audit := repository.NewORMAuditRepository(db)
+	exporter := export.NewExporter(repo, sessions, roles, blocks, audit)
+	h := handler.NewUserHandler(repo, tokenRepo, sessions, roles, audit, blocks, followRequests, revocations, guard, passwordPolicy, mailer, exporter, avatars, suggestions, cfg, appLogger)
+	admin := handler.NewAdminHandler(h, audit)
 	lis, err := net.Listen("tcp", cfg.Server.Port)
 	if err != nil {
 		appLogger.Fatal(err.Error())
@@ -93,10 +174,18 @@ func main() {
 			grpc_ctxtags.UnaryServerInterceptor(),
 			grpc_prometheus.UnaryServerInterceptor,
 			grpcrecovery.UnaryServerInterceptor(),
+			im.Auth,
+		),
+		grpc.ChainStreamInterceptor(
+			grpc_ctxtags.StreamServerInterceptor(),
+			grpc_prometheus.StreamServerInterceptor,
+			grpcrecovery.StreamServerInterceptor(),
+			im.StreamAuth,
 		),
 	)
 
 	pb.RegisterUsersServer(srv, h)
+	pb.RegisterUserAdminServer(srv, admin)
 	appLogger.Info("server starts at ", cfg.Server.Port)
 
 	if err := srv.Serve(lis); err != nil {
@@ -104,3 +193,94 @@ func main() {
 	}
 
 }
+
+// bootstrapAdmins grants the admin role to the users of the configured
+// admin emails. Only a single verified account with the email qualifies, so
+// signing up with an admin email is not enough to become admin. Grants are
+// recorded in the audit trail without an actor.
+func bootstrapAdmins(repo repository.UserRepository, roles repository.RoleRepository, emails []string, logger logger.Logger) {
+	ctx := context.Background()
+	for _, email := range emails {
+		users, err := repo.ListByEmail(ctx, email)
+		if err != nil {
+			logger.Errorf("find admin %s: %v", email, err)
+			continue
+		}
+		switch {
+		case len(users) == 0:
+			logger.Warnf("admin %s not found", email)
+			continue
+		case len(users) > 1:
+			logger.Warnf("admin %s not granted: %d accounts have the email", email, len(users))
+			continue
+		case !users[0].IsVerified():
+			logger.Warnf("admin %s not granted: email is not verified", email)
+			continue
+		}
+		current, err := roles.GetUserRoles(ctx, users[0].ID)
+		if err != nil {
+			logger.Fatalf("cannot get roles of admin %s: %v", email, err)
+		}
+		if hasRole(current, auth.RoleAdmin) {
+			continue
+		}
+		event := &model.AuditEvent{
+			Action:       handler.AuditGrantRole,
+			TargetUserID: users[0].ID,
+			Details:      `{"role":"admin","reason":"admin_emails"}`,
+		}
+		if err := roles.GrantRole(ctx, users[0].ID, auth.RoleAdmin, event); err != nil {
+			logger.Fatalf("cannot grant admin role: %v", err)
+		}
+	}
+}
+
+func hasRole(roles []*model.Role, name string) bool {
+	for _, r := range roles {
+		if r.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// sweepAccounts periodically lifts timed suspensions that expired, purges
+// accounts whose deletion grace period is over and prunes expired sessions.
+// Expired suspensions are already ignored on login, lifting them keeps the
+// stored status and the admin filters accurate.
+func sweepAccounts(repo repository.UserRepository, sessions repository.SessionRepository, avatars *avatar.Service, cfg config.AccountConfig, logger logger.Logger) {
+	interval := cfg.SweepInterval * time.Second
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	anonymize := cfg.PurgeMode == "anonymize"
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		now := time.Now()
+		n, err := repo.ReactivateExpired(context.Background(), now)
+		if err != nil {
+			logger.Errorf("reactivate accounts: %v", err)
+		} else if n > 0 {
+			logger.Infof("reactivated %d accounts after their suspension expired", n)
+		}
+		purged, err := repo.PurgeDeleted(context.Background(), now, anonymize)
+		if err != nil {
+			logger.Errorf("purge deleted accounts: %v", err)
+		}
+		if len(purged) > 0 {
+			logger.Infof("purged %d deleted accounts", len(purged))
+		}
+		for _, id := range purged {
+			if err := avatars.Delete(context.Background(), id); err != nil {
+				logger.Errorf("delete avatar of user %d: %v", id, err)
+			}
+		}
+		pruned, err := sessions.PruneSessions(context.Background(), now)
+		if err != nil {
+			logger.Errorf("prune sessions: %v", err)
+		} else if pruned > 0 {
+			logger.Infof("pruned %d expired sessions", pruned)
+		}
+	}
+}
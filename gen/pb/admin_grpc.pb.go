@@ -0,0 +1,451 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package proto
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// UserAdminClient is the client API for UserAdmin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type UserAdminClient interface {
+	ListUsers(ctx context.Context, in *ListUsersRequest, opts ...grpc.CallOption) (*ListUsersResponse, error)
+	GetUser(ctx context.Context, in *AdminUserRequest, opts ...grpc.CallOption) (*AdminUser, error)
+	UpdateUser(ctx context.Context, in *AdminUpdateUserRequest, opts ...grpc.CallOption) (*AdminUser, error)
+	ForcePasswordReset(ctx context.Context, in *AdminUserRequest, opts ...grpc.CallOption) (*Empty, error)
+	SuspendUser(ctx context.Context, in *SuspendUserRequest, opts ...grpc.CallOption) (*AdminUser, error)
+	UnsuspendUser(ctx context.Context, in *AdminUserRequest, opts ...grpc.CallOption) (*AdminUser, error)
+	SetUserStatus(ctx context.Context, in *SetUserStatusRequest, opts ...grpc.CallOption) (*AdminUser, error)
+	DeleteUser(ctx context.Context, in *AdminUserRequest, opts ...grpc.CallOption) (*Empty, error)
+	ExportUserData(ctx context.Context, in *AdminUserRequest, opts ...grpc.CallOption) (UserAdmin_ExportUserDataClient, error)
+	ListAuditEvents(ctx context.Context, in *ListAuditEventsRequest, opts ...grpc.CallOption) (*ListAuditEventsResponse, error)
+}
+
+type userAdminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewUserAdminClient(cc grpc.ClientConnInterface) UserAdminClient {
+	return &userAdminClient{cc}
+}
+
+func (c *userAdminClient) ListUsers(ctx context.Context, in *ListUsersRequest, opts ...grpc.CallOption) (*ListUsersResponse, error) {
+	out := new(ListUsersResponse)
+	err := c.cc.Invoke(ctx, "/user.UserAdmin/ListUsers", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAdminClient) GetUser(ctx context.Context, in *AdminUserRequest, opts ...grpc.CallOption) (*AdminUser, error) {
+	out := new(AdminUser)
+	err := c.cc.Invoke(ctx, "/user.UserAdmin/GetUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAdminClient) UpdateUser(ctx context.Context, in *AdminUpdateUserRequest, opts ...grpc.CallOption) (*AdminUser, error) {
+	out := new(AdminUser)
+	err := c.cc.Invoke(ctx, "/user.UserAdmin/UpdateUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAdminClient) ForcePasswordReset(ctx context.Context, in *AdminUserRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/user.UserAdmin/ForcePasswordReset", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAdminClient) SuspendUser(ctx context.Context, in *SuspendUserRequest, opts ...grpc.CallOption) (*AdminUser, error) {
+	out := new(AdminUser)
+	err := c.cc.Invoke(ctx, "/user.UserAdmin/SuspendUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAdminClient) UnsuspendUser(ctx context.Context, in *AdminUserRequest, opts ...grpc.CallOption) (*AdminUser, error) {
+	out := new(AdminUser)
+	err := c.cc.Invoke(ctx, "/user.UserAdmin/UnsuspendUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAdminClient) SetUserStatus(ctx context.Context, in *SetUserStatusRequest, opts ...grpc.CallOption) (*AdminUser, error) {
+	out := new(AdminUser)
+	err := c.cc.Invoke(ctx, "/user.UserAdmin/SetUserStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAdminClient) DeleteUser(ctx context.Context, in *AdminUserRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/user.UserAdmin/DeleteUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAdminClient) ExportUserData(ctx context.Context, in *AdminUserRequest, opts ...grpc.CallOption) (UserAdmin_ExportUserDataClient, error) {
+	stream, err := c.cc.NewStream(ctx, &UserAdmin_ServiceDesc.Streams[0], "/user.UserAdmin/ExportUserData", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &userAdminExportUserDataClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type UserAdmin_ExportUserDataClient interface {
+	Recv() (*ExportChunk, error)
+	grpc.ClientStream
+}
+
+type userAdminExportUserDataClient struct {
+	grpc.ClientStream
+}
+
+func (x *userAdminExportUserDataClient) Recv() (*ExportChunk, error) {
+	m := new(ExportChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *userAdminClient) ListAuditEvents(ctx context.Context, in *ListAuditEventsRequest, opts ...grpc.CallOption) (*ListAuditEventsResponse, error) {
+	out := new(ListAuditEventsResponse)
+	err := c.cc.Invoke(ctx, "/user.UserAdmin/ListAuditEvents", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UserAdminServer is the server API for UserAdmin service.
+// All implementations should embed UnimplementedUserAdminServer
+// for forward compatibility
+type UserAdminServer interface {
+	ListUsers(context.Context, *ListUsersRequest) (*ListUsersResponse, error)
+	GetUser(context.Context, *AdminUserRequest) (*AdminUser, error)
+	UpdateUser(context.Context, *AdminUpdateUserRequest) (*AdminUser, error)
+	ForcePasswordReset(context.Context, *AdminUserRequest) (*Empty, error)
+	SuspendUser(context.Context, *SuspendUserRequest) (*AdminUser, error)
+	UnsuspendUser(context.Context, *AdminUserRequest) (*AdminUser, error)
+	SetUserStatus(context.Context, *SetUserStatusRequest) (*AdminUser, error)
+	DeleteUser(context.Context, *AdminUserRequest) (*Empty, error)
+	ExportUserData(*AdminUserRequest, UserAdmin_ExportUserDataServer) error
+	ListAuditEvents(context.Context, *ListAuditEventsRequest) (*ListAuditEventsResponse, error)
+}
+
+// UnimplementedUserAdminServer should be embedded to have forward compatible implementations.
+type UnimplementedUserAdminServer struct {
+}
+
+func (UnimplementedUserAdminServer) ListUsers(context.Context, *ListUsersRequest) (*ListUsersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListUsers not implemented")
+}
+func (UnimplementedUserAdminServer) GetUser(context.Context, *AdminUserRequest) (*AdminUser, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUser not implemented")
+}
+func (UnimplementedUserAdminServer) UpdateUser(context.Context, *AdminUpdateUserRequest) (*AdminUser, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateUser not implemented")
+}
+func (UnimplementedUserAdminServer) ForcePasswordReset(context.Context, *AdminUserRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ForcePasswordReset not implemented")
+}
+func (UnimplementedUserAdminServer) SuspendUser(context.Context, *SuspendUserRequest) (*AdminUser, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SuspendUser not implemented")
+}
+func (UnimplementedUserAdminServer) UnsuspendUser(context.Context, *AdminUserRequest) (*AdminUser, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnsuspendUser not implemented")
+}
+func (UnimplementedUserAdminServer) SetUserStatus(context.Context, *SetUserStatusRequest) (*AdminUser, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetUserStatus not implemented")
+}
+func (UnimplementedUserAdminServer) DeleteUser(context.Context, *AdminUserRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteUser not implemented")
+}
+func (UnimplementedUserAdminServer) ExportUserData(*AdminUserRequest, UserAdmin_ExportUserDataServer) error {
+	return status.Errorf(codes.Unimplemented, "method ExportUserData not implemented")
+}
+func (UnimplementedUserAdminServer) ListAuditEvents(context.Context, *ListAuditEventsRequest) (*ListAuditEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAuditEvents not implemented")
+}
+
+// UnsafeUserAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to UserAdminServer will
+// result in compilation errors.
+type UnsafeUserAdminServer interface {
+	mustEmbedUnimplementedUserAdminServer()
+}
+
+func RegisterUserAdminServer(s grpc.ServiceRegistrar, srv UserAdminServer) {
+	s.RegisterService(&UserAdmin_ServiceDesc, srv)
+}
+
+func _UserAdmin_ListUsers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListUsersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAdminServer).ListUsers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/user.UserAdmin/ListUsers",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAdminServer).ListUsers(ctx, req.(*ListUsersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAdmin_GetUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdminUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAdminServer).GetUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/user.UserAdmin/GetUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAdminServer).GetUser(ctx, req.(*AdminUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAdmin_UpdateUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdminUpdateUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAdminServer).UpdateUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/user.UserAdmin/UpdateUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAdminServer).UpdateUser(ctx, req.(*AdminUpdateUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAdmin_ForcePasswordReset_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdminUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAdminServer).ForcePasswordReset(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/user.UserAdmin/ForcePasswordReset",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAdminServer).ForcePasswordReset(ctx, req.(*AdminUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAdmin_SuspendUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SuspendUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAdminServer).SuspendUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/user.UserAdmin/SuspendUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAdminServer).SuspendUser(ctx, req.(*SuspendUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAdmin_UnsuspendUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdminUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAdminServer).UnsuspendUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/user.UserAdmin/UnsuspendUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAdminServer).UnsuspendUser(ctx, req.(*AdminUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAdmin_SetUserStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetUserStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAdminServer).SetUserStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/user.UserAdmin/SetUserStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAdminServer).SetUserStatus(ctx, req.(*SetUserStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAdmin_DeleteUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdminUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAdminServer).DeleteUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/user.UserAdmin/DeleteUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAdminServer).DeleteUser(ctx, req.(*AdminUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAdmin_ExportUserData_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(AdminUserRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(UserAdminServer).ExportUserData(m, &userAdminExportUserDataServer{stream})
+}
+
+type UserAdmin_ExportUserDataServer interface {
+	Send(*ExportChunk) error
+	grpc.ServerStream
+}
+
+type userAdminExportUserDataServer struct {
+	grpc.ServerStream
+}
+
+func (x *userAdminExportUserDataServer) Send(m *ExportChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _UserAdmin_ListAuditEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAuditEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAdminServer).ListAuditEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/user.UserAdmin/ListAuditEvents",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAdminServer).ListAuditEvents(ctx, req.(*ListAuditEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// UserAdmin_ServiceDesc is the grpc.ServiceDesc for UserAdmin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var UserAdmin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "user.UserAdmin",
+	HandlerType: (*UserAdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListUsers",
+			Handler:    _UserAdmin_ListUsers_Handler,
+		},
+		{
+			MethodName: "GetUser",
+			Handler:    _UserAdmin_GetUser_Handler,
+		},
+		{
+			MethodName: "UpdateUser",
+			Handler:    _UserAdmin_UpdateUser_Handler,
+		},
+		{
+			MethodName: "ForcePasswordReset",
+			Handler:    _UserAdmin_ForcePasswordReset_Handler,
+		},
+		{
+			MethodName: "SuspendUser",
+			Handler:    _UserAdmin_SuspendUser_Handler,
+		},
+		{
+			MethodName: "UnsuspendUser",
+			Handler:    _UserAdmin_UnsuspendUser_Handler,
+		},
+		{
+			MethodName: "SetUserStatus",
+			Handler:    _UserAdmin_SetUserStatus_Handler,
+		},
+		{
+			MethodName: "DeleteUser",
+			Handler:    _UserAdmin_DeleteUser_Handler,
+		},
+		{
+			MethodName: "ListAuditEvents",
+			Handler:    _UserAdmin_ListAuditEvents_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExportUserData",
+			Handler:       _UserAdmin_ExportUserData_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "admin.proto",
+}
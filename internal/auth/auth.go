@@ -2,6 +2,8 @@ package auth
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"time"
@@ -16,54 +18,234 @@ func SetJWTSecret(secret string) {
 	JWTSecret = []byte(secret)
 }
 
+// RevocationList reports access tokens that were revoked before they expired
+type RevocationList interface {
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+var revocationList RevocationList
+
+// SetRevocationList sets the list every authenticated call is checked against
+func SetRevocationList(l RevocationList) {
+	revocationList = l
+}
+
+// SessionStore reports whether the session an access token was issued for
+// is still active
+type SessionStore interface {
+	IsSessionActive(ctx context.Context, sessionID string) (bool, error)
+}
+
+var sessionStore SessionStore
+
+// SetSessionStore sets the store the session of every authenticated call is
+// checked against
+func SetSessionStore(s SessionStore) {
+	sessionStore = s
+}
+
+// AccountStore reports whether the status of an account still allows its
+// tokens to be used
+type AccountStore interface {
+	IsAccountActive(ctx context.Context, userID uint) (bool, error)
+}
+
+var accountStore AccountStore
+
+// SetAccountStore sets the store the account of every authenticated call is
+// checked against
+func SetAccountStore(s AccountStore) {
+	accountStore = s
+}
+
+const (
+	// AccessTokenDuration is the lifetime of an access token
+	AccessTokenDuration = time.Hour * 3
+	// MFAChallengeDuration is the lifetime of an MFA challenge token
+	MFAChallengeDuration = time.Minute * 5
+)
+
+// PurposeMFA marks a token that can only be exchanged through VerifyMFA
+const PurposeMFA = "mfa"
+
+// Token audiences. Services verifying access tokens through the JWKS must
+// require AccessTokenAudience; MFA challenges are never signed with the
+// published keys.
+const (
+	AccessTokenAudience  = "service-user/access"
+	MFAChallengeAudience = "service-user/mfa"
+)
+
 type Claims struct {
-	UserID uint `json:"user_id"`
+	UserID      uint     `json:"user_id"`
+	SessionID   string   `json:"sid,omitempty"`
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"perms,omitempty"`
+	// Purpose is empty for access tokens
+	Purpose string `json:"pur,omitempty"`
 	jwt.StandardClaims
 }
 
-func GenerateToken(uid uint) (string, error) {
+// GenerateToken generates an access token for the user; sessionID ties the
+// token to the session and refresh token family it was issued from. The
+// roles and permissions of the user are copied into the token, so changes
+// apply once the token is refreshed.
+func GenerateToken(uid uint, sessionID string, roles, permissions []string) (string, error) {
+	jti, err := randomString(16)
+	if err != nil {
+		return "", err
+	}
 	claims := &Claims{
-		uid,
-		jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Hour * 3).Unix(),
+		UserID:      uid,
+		SessionID:   sessionID,
+		Roles:       roles,
+		Permissions: permissions,
+		StandardClaims: jwt.StandardClaims{
+			Id:        jti,
+			Audience:  AccessTokenAudience,
+			ExpiresAt: time.Now().Add(AccessTokenDuration).Unix(),
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	t, err := token.SignedString(JWTSecret)
+	return keyManager.Sign(claims)
+}
+
+// GenerateMFAChallenge generates a short-lived token proving the user passed
+// the password step of a login that still needs a second factor. It is
+// signed with a server-only HMAC key, so verifiers of access tokens cannot
+// accept it.
+func GenerateMFAChallenge(uid uint) (string, error) {
+	key, err := mfaChallengeKey()
 	if err != nil {
 		return "", err
 	}
-	return t, nil
+	jti, err := randomString(16)
+	if err != nil {
+		return "", err
+	}
+	claims := &Claims{
+		UserID:  uid,
+		Purpose: PurposeMFA,
+		StandardClaims: jwt.StandardClaims{
+			Id:        jti,
+			Audience:  MFAChallengeAudience,
+			ExpiresAt: time.Now().Add(MFAChallengeDuration).Unix(),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+}
+
+// ParseMFAChallenge validates an MFA challenge token
+func ParseMFAChallenge(tokenString string) (*Claims, error) {
+	key, err := mfaChallengeKey()
+	if err != nil {
+		return nil, err
+	}
+	c, err := parseToken(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if token.Method != jwt.SigningMethodHS256 {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		return key, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if c.Purpose != PurposeMFA || !c.VerifyAudience(MFAChallengeAudience, true) {
+		return nil, errors.New("invalid token: not an mfa challenge")
+	}
+	return c, nil
+}
+
+// mfaChallengeKey derives the key of MFA challenges from the verification
+// secret, which never leaves the server
+func mfaChallengeKey() ([]byte, error) {
+	if len(verificationSecret) == 0 {
+		return nil, errors.New("no verification secret configured")
+	}
+	mac := hmac.New(sha256.New, verificationSecret)
+	mac.Write([]byte("mfa-challenge"))
+	return mac.Sum(nil), nil
+}
+
+// JWKS returns the public keys that verify access tokens; verifiers must
+// also require the AccessTokenAudience audience
+func JWKS() []JWK {
+	return keyManager.JWKS()
 }
 
 // GetUserID gets user id string from request context
 func GetUserID(ctx context.Context) (uint, error) {
-	tokenString, err := grpc_auth.AuthFromMD(ctx, "Token")
+	c, err := GetClaims(ctx)
 	if err != nil {
 		return 0, err
 	}
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return JWTSecret, nil
-	})
-	if !token.Valid {
+	return c.UserID, nil
+}
+
+// GetClaims parses and validates the access token of the request context
+func GetClaims(ctx context.Context) (*Claims, error) {
+	tokenString, err := grpc_auth.AuthFromMD(ctx, "Token")
+	if err != nil {
+		return nil, err
+	}
+	c, err := parseToken(tokenString, keyManager.Keyfunc)
+	if err != nil {
+		return nil, err
+	}
+	if c.Purpose != "" || !c.VerifyAudience(AccessTokenAudience, true) {
+		return nil, errors.New("invalid token: not an access token")
+	}
+	if revocationList != nil {
+		revoked, err := revocationList.IsRevoked(ctx, c.Id)
+		if err != nil {
+			return nil, fmt.Errorf("check token revocation: %w", err)
+		}
+		if revoked {
+			return nil, errors.New("token revoked")
+		}
+	}
+	if sessionStore != nil && c.SessionID != "" {
+		active, err := sessionStore.IsSessionActive(ctx, c.SessionID)
+		if err != nil {
+			return nil, fmt.Errorf("check session: %w", err)
+		}
+		if !active {
+			return nil, errors.New("session revoked")
+		}
+	}
+	if accountStore != nil {
+		active, err := accountStore.IsAccountActive(ctx, c.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("check account: %w", err)
+		}
+		if !active {
+			return nil, errors.New("account is not active")
+		}
+	}
+	return c, nil
+}
+
+// parseToken checks the signature and expiry of a token
+func parseToken(tokenString string, keyfunc jwt.Keyfunc) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, keyfunc)
+	if token == nil || !token.Valid {
 		if ve, ok := err.(*jwt.ValidationError); ok {
 			if ve.Errors&jwt.ValidationErrorMalformed != 0 {
-				return 0, errors.New("invalid token: it's not even a token")
+				return nil, errors.New("invalid token: it's not even a token")
 			} else if ve.Errors&(jwt.ValidationErrorExpired|jwt.ValidationErrorNotValidYet) != 0 {
-				return 0, errors.New("token expired")
+				return nil, errors.New("token expired")
 			} else {
-				return 0, fmt.Errorf("invalid token: couldn't handle this token; %w", err)
+				return nil, fmt.Errorf("invalid token: couldn't handle this token; %w", err)
 			}
 		} else {
-			return 0, fmt.Errorf("invalid token: couldn't handle this token; %w", err)
+			return nil, fmt.Errorf("invalid token: couldn't handle this token; %w", err)
 		}
 	}
 	c, ok := token.Claims.(*Claims)
 	if !ok {
-		return 0, errors.New("invalid token: cannot map token to claims")
+		return nil, errors.New("invalid token: cannot map token to claims")
 	}
 	if c.ExpiresAt < time.Now().Unix() {
-		return 0, errors.New("token expired")
+		return nil, errors.New("token expired")
 	}
-	return c.UserID, nil
+	return c, nil
 }
@@ -0,0 +1,268 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/dgrijalva/jwt-go"
+
+	"github.com/rezaAmiri123/service-user/cmd/config"
+)
+
+// Key is a JWT signing or verification key
+type Key struct {
+	ID         string
+	Algorithm  string
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+}
+
+// JWK is the JSON Web Key representation of a public key
+type JWK struct {
+	Kty string
+	Kid string
+	Use string
+	Alg string
+	N   string
+	E   string
+	Crv string
+	X   string
+	Y   string
+}
+
+// KeyManager signs tokens with one key and verifies them with every known
+// key, so keys can be rotated without invalidating issued tokens. Without
+// any key it falls back to HS256 with JWTSecret.
+type KeyManager struct {
+	mu      sync.RWMutex
+	signing *Key
+	keys    map[string]*Key
+}
+
+func NewKeyManager() *KeyManager {
+	return &KeyManager{keys: make(map[string]*Key)}
+}
+
+var keyManager = NewKeyManager()
+
+// SetKeyManager sets the key manager used to sign and verify tokens
+func SetKeyManager(m *KeyManager) {
+	keyManager = m
+}
+
+// LoadKeyManager loads the configured keys from their PEM files
+func LoadKeyManager(cfg config.CommonConfig) (*KeyManager, error) {
+	m := NewKeyManager()
+	for _, kc := range cfg.Keys {
+		k, err := loadKey(kc)
+		if err != nil {
+			return nil, fmt.Errorf("load key %q: %w", kc.ID, err)
+		}
+		if err := m.AddKey(k); err != nil {
+			return nil, err
+		}
+	}
+	if len(cfg.Keys) > 0 {
+		signingKeyID := cfg.SigningKeyID
+		if signingKeyID == "" {
+			signingKeyID = cfg.Keys[0].ID
+		}
+		if err := m.SetSigningKey(signingKeyID); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func loadKey(kc config.KeyConfig) (*Key, error) {
+	k := &Key{ID: kc.ID}
+	if kc.PrivateKeyFile != "" {
+		data, err := ioutil.ReadFile(kc.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if rsaKey, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+			k.PrivateKey = rsaKey
+		} else if ecKey, err := jwt.ParseECPrivateKeyFromPEM(data); err == nil {
+			k.PrivateKey = ecKey
+		} else {
+			return nil, errors.New("unsupported private key, want RSA or EC P-256")
+		}
+		k.PublicKey = k.PrivateKey.Public()
+	} else if kc.PublicKeyFile != "" {
+		data, err := ioutil.ReadFile(kc.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if rsaKey, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+			k.PublicKey = rsaKey
+		} else if ecKey, err := jwt.ParseECPublicKeyFromPEM(data); err == nil {
+			k.PublicKey = ecKey
+		} else {
+			return nil, errors.New("unsupported public key, want RSA or EC P-256")
+		}
+	} else {
+		return nil, errors.New("no key file")
+	}
+	return k, nil
+}
+
+// AddKey adds a key; its algorithm and, when empty, its id are derived
+// from the public key
+func (m *KeyManager) AddKey(k *Key) error {
+	switch pub := k.PublicKey.(type) {
+	case *rsa.PublicKey:
+		k.Algorithm = jwt.SigningMethodRS256.Alg()
+	case *ecdsa.PublicKey:
+		if pub.Curve != elliptic.P256() {
+			return errors.New("unsupported EC curve, want P-256")
+		}
+		k.Algorithm = jwt.SigningMethodES256.Alg()
+	default:
+		return errors.New("unsupported key type")
+	}
+	if k.ID == "" {
+		k.ID = thumbprint(k.jwk())
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.keys[k.ID]; ok {
+		return fmt.Errorf("duplicate key id %q", k.ID)
+	}
+	m.keys[k.ID] = k
+	return nil
+}
+
+// SetSigningKey selects the key that signs new tokens
+func (m *KeyManager) SetSigningKey(kid string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k, ok := m.keys[kid]
+	if !ok {
+		return fmt.Errorf("unknown signing key %q", kid)
+	}
+	if k.PrivateKey == nil {
+		return fmt.Errorf("signing key %q has no private key", kid)
+	}
+	m.signing = k
+	return nil
+}
+
+// RemoveKey drops a retired key; tokens signed with it stop verifying
+func (m *KeyManager) RemoveKey(kid string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.signing != nil && m.signing.ID == kid {
+		return errors.New("cannot remove the signing key")
+	}
+	delete(m.keys, kid)
+	return nil
+}
+
+// Sign signs the claims with the signing key and stamps its kid header
+func (m *KeyManager) Sign(claims jwt.Claims) (string, error) {
+	m.mu.RLock()
+	k := m.signing
+	m.mu.RUnlock()
+
+	if k == nil {
+		if len(JWTSecret) == 0 {
+			return "", errors.New("no signing key configured")
+		}
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(JWTSecret)
+	}
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(k.Algorithm), claims)
+	token.Header["kid"] = k.ID
+	return token.SignedString(k.PrivateKey)
+}
+
+// Keyfunc returns the verification key for a token, checking that the
+// token algorithm matches the key
+func (m *KeyManager) Keyfunc(token *jwt.Token) (interface{}, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.keys) == 0 {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok || len(JWTSecret) == 0 {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		return JWTSecret, nil
+	}
+	kid, _ := token.Header["kid"].(string)
+	k, ok := m.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if token.Method.Alg() != k.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+	}
+	return k.PublicKey, nil
+}
+
+// JWKS returns the public keys as a JSON Web Key Set, sorted by key id
+func (m *KeyManager) JWKS() []JWK {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	set := make([]JWK, 0, len(m.keys))
+	for _, k := range m.keys {
+		set = append(set, k.jwk())
+	}
+	sort.Slice(set, func(i, j int) bool { return set[i].Kid < set[j].Kid })
+	return set
+}
+
+func (k *Key) jwk() JWK {
+	j := JWK{Kid: k.ID, Use: "sig", Alg: k.Algorithm}
+	switch pub := k.PublicKey.(type) {
+	case *rsa.PublicKey:
+		j.Kty = "RSA"
+		j.N = b64(pub.N.Bytes())
+		j.E = b64(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		j.Kty = "EC"
+		j.Crv = pub.Curve.Params().Name
+		j.X = b64(padded(pub.X.Bytes(), size))
+		j.Y = b64(padded(pub.Y.Bytes(), size))
+	}
+	return j
+}
+
+// thumbprint is the RFC 7638 JWK thumbprint of a public key
+func thumbprint(j JWK) string {
+	var canonical string
+	if j.Kty == "RSA" {
+		canonical = fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, j.E, j.N)
+	} else {
+		canonical = fmt.Sprintf(`{"crv":"%s","kty":"EC","x":"%s","y":"%s"}`, j.Crv, j.X, j.Y)
+	}
+	sum := sha256.Sum256([]byte(canonical))
+	return b64(sum[:])
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func padded(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	p := make([]byte, size)
+	copy(p[size-len(b):], b)
+	return p
+}
@@ -0,0 +1,41 @@
+package auth
+
+// Permissions checked by the auth interceptor
+const (
+	PermissionUnlockUsers  = "users:unlock"
+	PermissionImportUsers  = "users:import"
+	PermissionManageRoles  = "roles:manage"
+	PermissionReadUsers    = "users:read"
+	PermissionWriteUsers   = "users:write"
+	PermissionSuspendUsers = "users:suspend"
+	PermissionDeleteUsers  = "users:delete"
+	PermissionReadAudit    = "audit:read"
+	PermissionExportUsers  = "users:export"
+)
+
+// Built-in roles
+const (
+	RoleAdmin     = "admin"
+	RoleModerator = "moderator"
+)
+
+// DefaultRoles are the built-in roles and their permissions; they are
+// created at startup when missing
+var DefaultRoles = map[string][]string{
+	RoleAdmin: {
+		PermissionUnlockUsers,
+		PermissionImportUsers,
+		PermissionManageRoles,
+		PermissionReadUsers,
+		PermissionWriteUsers,
+		PermissionSuspendUsers,
+		PermissionDeleteUsers,
+		PermissionReadAudit,
+		PermissionExportUsers,
+	},
+	RoleModerator: {
+		PermissionUnlockUsers,
+		PermissionReadUsers,
+		PermissionSuspendUsers,
+	},
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"context"
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	UserID      uint
+	SessionID   string
+	Roles       []string
+	Permissions []string
+	TokenID     string
+	ExpiresAt   int64
+}
+
+// HasRole reports whether the principal has the role
+func (p *Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission reports whether one of the roles of the principal grants
+// the permission
+func (p *Principal) HasPermission(permission string) bool {
+	for _, perm := range p.Permissions {
+		if perm == permission {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+// NewPrincipal returns the principal described by token claims
+func NewPrincipal(c *Claims) *Principal {
+	return &Principal{
+		UserID:      c.UserID,
+		SessionID:   c.SessionID,
+		Roles:       c.Roles,
+		Permissions: c.Permissions,
+		TokenID:     c.Id,
+		ExpiresAt:   c.ExpiresAt,
+	}
+}
+
+// ContextWithPrincipal returns a copy of ctx carrying the principal
+func ContextWithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the principal placed in ctx by the auth
+// interceptor
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+const (
+	// RefreshTokenDuration is the lifetime of a refresh token
+	RefreshTokenDuration = time.Hour * 24 * 30
+	// PasswordResetDuration is the lifetime of a password reset token
+	PasswordResetDuration = time.Hour
+)
+
+// GenerateRefreshToken returns a new opaque refresh token and its hash.
+// Only the hash should be persisted.
+func GenerateRefreshToken() (token string, hash string, err error) {
+	return GenerateOpaqueToken()
+}
+
+// GenerateOpaqueToken returns a new random token and its hash
+func GenerateOpaqueToken() (token string, hash string, err error) {
+	token, err = randomString(32)
+	if err != nil {
+		return "", "", err
+	}
+	return token, HashToken(token), nil
+}
+
+// NewTokenFamily returns a new random refresh token family id
+func NewTokenFamily() (string, error) {
+	return randomString(16)
+}
+
+// HashToken returns the hex encoded SHA-256 hash of an opaque token
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 secret of the RFC 6238 test vectors,
+// "12345678901234567890", base32 encoded
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidateTOTP(t *testing.T) {
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		at       int64
+		wantStep int64
+		wantOK   bool
+	}{
+		// the last six digits of the RFC 6238 SHA-1 vectors
+		{"rfc vector 59", rfc6238Secret, "287082", 59, 1, true},
+		{"rfc vector 1111111109", rfc6238Secret, "081804", 1111111109, 37037036, true},
+		{"rfc vector 1234567890", rfc6238Secret, "005924", 1234567890, 41152263, true},
+		{"rfc vector 2000000000", rfc6238Secret, "279037", 2000000000, 66666666, true},
+		{"lower case and padded secret", strings.ToLower(rfc6238Secret) + "====", "287082", 59, 1, true},
+		{"previous step within skew", rfc6238Secret, "287082", 89, 1, true},
+		{"next step within skew", rfc6238Secret, "081804", 1111111109 - totpPeriod, 37037036, true},
+		{"two steps late", rfc6238Secret, "287082", 59 + 2*totpPeriod, 0, false},
+		{"wrong code", rfc6238Secret, "287083", 59, 0, false},
+		{"short code", rfc6238Secret, "28708", 59, 0, false},
+		{"long code", rfc6238Secret, "2870820", 59, 0, false},
+		{"invalid secret", "not base32!", "287082", 59, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := ValidateTOTP(tt.secret, tt.code, time.Unix(tt.at, 0))
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("ValidateTOTP = %d, %v, want %d, %v", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestTOTPSecretEncryption(t *testing.T) {
+	SetTOTPEncryptionKey("test key")
+	defer SetTOTPEncryptionKey("")
+
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret: %v", err)
+	}
+	sealed, err := EncryptTOTPSecret(secret)
+	if err != nil {
+		t.Fatalf("EncryptTOTPSecret: %v", err)
+	}
+	if strings.Contains(sealed, secret) {
+		t.Fatal("encrypted secret contains the plain secret")
+	}
+	tampered := []byte(sealed)
+	if i := len(tampered) - 5; tampered[i] == 'A' {
+		tampered[i] = 'B'
+	} else {
+		tampered[i] = 'A'
+	}
+
+	tests := []struct {
+		name    string
+		stored  string
+		want    string
+		wantErr bool
+	}{
+		{"encrypted", sealed, secret, false},
+		{"stored before encryption", rfc6238Secret, rfc6238Secret, false},
+		{"tampered", string(tampered), "", true},
+		{"truncated", sealedTOTPPrefix + "AAAA", "", true},
+		{"not base64", sealedTOTPPrefix + "!!!!", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecryptTOTPSecret(tt.stored)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecryptTOTPSecret error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("DecryptTOTPSecret = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
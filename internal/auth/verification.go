@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EmailVerificationDuration is the lifetime of an email verification code
+const EmailVerificationDuration = time.Hour * 24
+
+var verificationSecret []byte
+
+// SetVerificationSecret sets the HMAC key of email verification codes
+func SetVerificationSecret(secret string) {
+	verificationSecret = []byte(secret)
+}
+
+// GenerateVerificationCode returns a signed code that proves ownership of
+// email by the user until it expires
+func GenerateVerificationCode(uid uint, email string) (string, error) {
+	if len(verificationSecret) == 0 {
+		return "", errors.New("no verification secret configured")
+	}
+	expiresAt := time.Now().Add(EmailVerificationDuration).Unix()
+	payload := fmt.Sprintf("%d|%d|%s", uid, expiresAt, email)
+	return b64([]byte(payload)) + "." + b64(signVerification(payload)), nil
+}
+
+// ParseVerificationCode checks the signature and expiry of a verification
+// code and returns the user and email it was issued for
+func ParseVerificationCode(code string) (uint, string, error) {
+	invalid := errors.New("invalid verification code")
+	parts := strings.SplitN(code, ".", 2)
+	if len(parts) != 2 {
+		return 0, "", invalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return 0, "", invalid
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || len(verificationSecret) == 0 || !hmac.Equal(sig, signVerification(string(payload))) {
+		return 0, "", invalid
+	}
+	fields := strings.SplitN(string(payload), "|", 3)
+	if len(fields) != 3 {
+		return 0, "", invalid
+	}
+	uid, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return 0, "", invalid
+	}
+	expiresAt, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return 0, "", invalid
+	}
+	if time.Now().Unix() > expiresAt {
+		return 0, "", errors.New("verification code expired")
+	}
+	return uint(uid), fields[2], nil
+}
+
+func signVerification(payload string) []byte {
+	mac := hmac.New(sha256.New, verificationSecret)
+	mac.Write([]byte("email-verification|" + payload))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,179 @@
+package avatar
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/rezaAmiri123/service-user/cmd/config"
+	"github.com/rezaAmiri123/service-user/pkg/blob"
+	"github.com/rezaAmiri123/service-user/pkg/utils"
+)
+
+// jpegQuality is the quality of the stored images
+const jpegQuality = 85
+
+var (
+	// ErrTooLarge is returned for images over the configured size or
+	// dimensions
+	ErrTooLarge = errors.New("image is too large")
+	// ErrUnsupportedType is returned for anything but JPEG, PNG and GIF
+	ErrUnsupportedType = errors.New("unsupported image type, use jpeg, png or gif")
+)
+
+// allowedTypes are the content types accepted for uploads
+var allowedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// Service validates uploaded avatars, resizes them to square JPEG images of
+// the configured sizes and keeps them in a blob store
+type Service struct {
+	store        blob.Store
+	maxSize      int64
+	maxDimension int
+	sizes        []int
+}
+
+func NewService(store blob.Store, cfg config.AvatarConfig) *Service {
+	sizes := append([]int(nil), cfg.Sizes...)
+	sort.Ints(sizes)
+	if len(sizes) == 0 {
+		sizes = []int{256}
+	}
+	return &Service{
+		store:        store,
+		maxSize:      cfg.MaxSize * 1024,
+		maxDimension: cfg.MaxDimension,
+		sizes:        sizes,
+	}
+}
+
+// MaxSize returns the maximum size in bytes of an upload
+func (s *Service) MaxSize() int64 {
+	return s.maxSize
+}
+
+// Save stores every size of the avatar of a user and returns the URL of the
+// largest. The URL carries a version so clients do not keep showing a
+// replaced avatar from their cache.
+func (s *Service) Save(ctx context.Context, userID uint, data []byte) (string, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Avatar.Save")
+	defer span.Finish()
+
+	if s.maxSize > 0 && int64(len(data)) > s.maxSize {
+		return "", ErrTooLarge
+	}
+	if !allowedTypes[http.DetectContentType(data)] {
+		return "", ErrUnsupportedType
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", ErrUnsupportedType
+	}
+	if s.maxDimension > 0 && (cfg.Width > s.maxDimension || cfg.Height > s.maxDimension) {
+		return "", ErrTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("decode image: %v", err)
+	}
+	src := squareCrop(img)
+
+	var key string
+	for _, size := range s.sizes {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, resize(src, size), &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return "", err
+		}
+		key = fmt.Sprintf("%s/%d.jpg", prefix(userID), size)
+		if err := s.store.Put(ctx, key, "image/jpeg", &buf); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("%s?v=%d", s.store.URL(key), time.Now().Unix()), nil
+}
+
+// Delete removes every stored size of the avatar of a user
+func (s *Service) Delete(ctx context.Context, userID uint) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Avatar.Delete")
+	defer span.Finish()
+
+	return s.store.Delete(ctx, prefix(userID))
+}
+
+func prefix(userID uint) string {
+	return "avatars/" + utils.UintToString(userID)
+}
+
+// squareCrop returns the centered square of img
+func squareCrop(img image.Image) image.Image {
+	b := img.Bounds()
+	side := b.Dx()
+	if b.Dy() < side {
+		side = b.Dy()
+	}
+	x := b.Min.X + (b.Dx()-side)/2
+	y := b.Min.Y + (b.Dy()-side)/2
+	dst := image.NewRGBA(image.Rect(0, 0, side, side))
+	// transparent pixels become white as JPEG has no alpha channel
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, image.Pt(x, y), draw.Over)
+	return dst
+}
+
+// resize scales a square image to size by size pixels. Every destination
+// pixel averages the source pixels it covers, which keeps downscaled
+// images smooth; upscaling repeats pixels.
+func resize(src image.Image, size int) image.Image {
+	b := src.Bounds()
+	side := b.Dx()
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	for dy := 0; dy < size; dy++ {
+		y0, y1 := span(dy, size, side)
+		for dx := 0; dx < size; dx++ {
+			x0, x1 := span(dx, size, side)
+			var r, g, bl, n uint64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					pr, pg, pb, _ := src.At(b.Min.X+x, b.Min.Y+y).RGBA()
+					r += uint64(pr)
+					g += uint64(pg)
+					bl += uint64(pb)
+					n++
+				}
+			}
+			dst.SetRGBA(dx, dy, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(bl / n >> 8),
+				A: 0xff,
+			})
+		}
+	}
+	return dst
+}
+
+// span returns the source pixels [from, to) covered by destination pixel i
+func span(i, size, side int) (int, int) {
+	from := i * side / size
+	to := (i + 1) * side / size
+	if to <= from {
+		to = from + 1
+	}
+	return from, to
+}
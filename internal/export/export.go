@@ -0,0 +1,270 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/rezaAmiri123/service-user/internal/model"
+	"github.com/rezaAmiri123/service-user/internal/repository"
+)
+
+// Format identifies the layout of an export archive
+const Format = "service-user-export/v1"
+
+// pageSize is the number of rows loaded at once for the lists of an export
+const pageSize = 500
+
+// Exporter writes the data kept about a user as one JSON document, for data
+// subject access requests. Password hashes, TOTP secrets and token hashes
+// are left out.
+type Exporter struct {
+	users    repository.UserRepository
+	sessions repository.SessionRepository
+	roles    repository.RoleRepository
+	blocks   repository.BlockRepository
+	audit    repository.AuditRepository
+}
+
+func NewExporter(
+	users repository.UserRepository,
+	sessions repository.SessionRepository,
+	roles repository.RoleRepository,
+	blocks repository.BlockRepository,
+	audit repository.AuditRepository,
+) *Exporter {
+	return &Exporter{users: users, sessions: sessions, roles: roles, blocks: blocks, audit: audit}
+}
+
+type profile struct {
+	ID           uint      `json:"id"`
+	Username     string    `json:"username"`
+	Email        string    `json:"email"`
+	PendingEmail string    `json:"pending_email,omitempty"`
+	Bio          string    `json:"bio"`
+	Image        string    `json:"image"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type settings struct {
+	EmailVerified         bool       `json:"email_verified"`
+	VerifiedAt            *time.Time `json:"verified_at,omitempty"`
+	MFAEnabled            bool       `json:"mfa_enabled"`
+	Private               bool       `json:"private"`
+	Status                string     `json:"status"`
+	StatusReason          string     `json:"status_reason,omitempty"`
+	StatusExpiresAt       *time.Time `json:"status_expires_at,omitempty"`
+	PasswordResetRequired bool       `json:"password_reset_required"`
+}
+
+type userRef struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+}
+
+type session struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+type auditEvent struct {
+	ID           uint            `json:"id"`
+	ActorID      uint            `json:"actor_id"`
+	Action       string          `json:"action"`
+	TargetUserID uint            `json:"target_user_id"`
+	Details      json.RawMessage `json:"details,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+// Export writes the archive of a user to w
+func (e *Exporter) Export(ctx context.Context, u *model.User, w io.Writer) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Exporter.Export")
+	defer span.Finish()
+
+	now := time.Now()
+	o := &objectWriter{w: w}
+	o.begin()
+	o.field("format", Format)
+	o.field("exported_at", now.UTC())
+	o.field("profile", profile{
+		ID:           u.ID,
+		Username:     u.Username,
+		Email:        u.Email,
+		PendingEmail: u.PendingEmail,
+		Bio:          u.Bio,
+		Image:        u.Image,
+		CreatedAt:    u.CreatedAt,
+		UpdatedAt:    u.UpdatedAt,
+	})
+	o.field("settings", settings{
+		EmailVerified:         u.IsVerified(),
+		VerifiedAt:            u.VerifiedAt,
+		MFAEnabled:            u.TOTPEnabled,
+		Private:               u.Private,
+		Status:                u.CurrentStatus(now),
+		StatusReason:          u.StatusReason,
+		StatusExpiresAt:       u.StatusExpiresAt,
+		PasswordResetRequired: u.PasswordResetRequired,
+	})
+
+	roles, err := e.roles.GetUserRoles(ctx, u.ID)
+	if err != nil {
+		return err
+	}
+	o.field("roles", model.RoleNames(roles))
+
+	o.array("following", func(emit func(interface{})) error {
+		return e.follows(ctx, u.ID, e.users.ListFollowing, emit)
+	})
+	o.array("followers", func(emit func(interface{})) error {
+		return e.follows(ctx, u.ID, e.users.ListFollowers, emit)
+	})
+	o.array("blocked", func(emit func(interface{})) error {
+		return e.follows(ctx, u.ID, e.blocks.ListBlocked, emit)
+	})
+	o.array("sessions", func(emit func(interface{})) error {
+		sessions, err := e.sessions.ListSessions(ctx, u.ID)
+		if err != nil {
+			return err
+		}
+		for _, s := range sessions {
+			emit(session{
+				ID:         s.SessionID,
+				UserAgent:  s.UserAgent,
+				IP:         s.IP,
+				CreatedAt:  s.CreatedAt,
+				LastSeenAt: s.LastSeenAt,
+			})
+		}
+		return nil
+	})
+	o.array("audit_events", func(emit func(interface{})) error {
+		return e.auditEvents(ctx, repository.AuditFilter{TargetUserID: u.ID}, emit)
+	})
+	o.array("staff_actions", func(emit func(interface{})) error {
+		return e.auditEvents(ctx, repository.AuditFilter{ActorID: u.ID}, emit)
+	})
+	o.end()
+	return o.err
+}
+
+type listFunc func(ctx context.Context, userID, afterID uint, limit int) ([]*model.User, error)
+
+// follows emits every user of a follow or block list
+func (e *Exporter) follows(ctx context.Context, userID uint, list listFunc, emit func(interface{})) error {
+	var after uint
+	for {
+		users, err := list(ctx, userID, after, pageSize)
+		if err != nil {
+			return err
+		}
+		for _, f := range users {
+			emit(userRef{ID: f.ID, Username: f.Username})
+		}
+		if len(users) < pageSize {
+			return nil
+		}
+		after = users[len(users)-1].ID
+	}
+}
+
+func (e *Exporter) auditEvents(ctx context.Context, filter repository.AuditFilter, emit func(interface{})) error {
+	filter.Limit = pageSize
+	for {
+		events, err := e.audit.List(ctx, filter)
+		if err != nil {
+			return err
+		}
+		for _, ev := range events {
+			out := auditEvent{
+				ID:           ev.ID,
+				ActorID:      ev.ActorID,
+				Action:       ev.Action,
+				TargetUserID: ev.TargetUserID,
+				CreatedAt:    ev.CreatedAt,
+			}
+			if ev.Details != "" {
+				out.Details = json.RawMessage(ev.Details)
+			}
+			emit(out)
+		}
+		if len(events) < pageSize {
+			return nil
+		}
+		filter.BeforeID = events[len(events)-1].ID
+	}
+}
+
+// objectWriter writes a JSON object one field at a time. The first error
+// stops every further write and is kept in err.
+type objectWriter struct {
+	w      io.Writer
+	fields int
+	err    error
+}
+
+func (o *objectWriter) write(b []byte) {
+	if o.err != nil {
+		return
+	}
+	_, o.err = o.w.Write(b)
+}
+
+func (o *objectWriter) value(v interface{}) {
+	if o.err != nil {
+		return
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		o.err = err
+		return
+	}
+	o.write(b)
+}
+
+func (o *objectWriter) begin() {
+	o.write([]byte("{"))
+}
+
+func (o *objectWriter) end() {
+	o.write([]byte("}\n"))
+}
+
+func (o *objectWriter) key(name string) {
+	if o.fields > 0 {
+		o.write([]byte(","))
+	}
+	o.fields++
+	o.value(name)
+	o.write([]byte(":"))
+}
+
+func (o *objectWriter) field(name string, v interface{}) {
+	o.key(name)
+	o.value(v)
+}
+
+// array writes a field holding the values fill emits
+func (o *objectWriter) array(name string, fill func(emit func(interface{})) error) {
+	o.key(name)
+	o.write([]byte("["))
+	n := 0
+	err := fill(func(v interface{}) {
+		if n > 0 {
+			o.write([]byte(","))
+		}
+		n++
+		o.value(v)
+	})
+	if err != nil && o.err == nil {
+		o.err = err
+	}
+	o.write([]byte("]"))
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/rezaAmiri123/service-user/gen/pb"
+	"github.com/rezaAmiri123/service-user/internal/model"
+)
+
+// DeleteAccount schedules the current user's account for deletion after the
+// password is confirmed. The user is signed out everywhere and can restore
+// the account by logging in during the grace period.
+func (h *UserHandler) DeleteAccount(ctx context.Context, req *pb.DeleteAccountRequest) (*pb.DeleteAccountResponse, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "user.DeleteAccount")
+	defer span.Finish()
+
+	u, err := h.getUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !u.CheckPassword(req.GetPassword()) {
+		return nil, status.Error(codes.PermissionDenied, "wrong password")
+	}
+	purgeAt := time.Now().Add(h.cfg.Account.DeletionGracePeriod * time.Hour)
+	u.SetStatus(model.UserStatusPendingDeletion, "deleted by user", &purgeAt)
+	if err := h.repo.Update(ctx, u, model.StatusColumns...); err != nil {
+		msg := fmt.Sprintf("failed to delete account: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	if err := h.signOut(ctx, u.ID); err != nil {
+		msg := fmt.Sprintf("failed to sign out: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	return &pb.DeleteAccountResponse{PurgeAt: timestamppb.New(purgeAt)}, nil
+}
@@ -0,0 +1,419 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/rezaAmiri123/service-user/gen/pb"
+	"github.com/rezaAmiri123/service-user/internal/model"
+	"github.com/rezaAmiri123/service-user/internal/repository"
+	"github.com/rezaAmiri123/service-user/pkg/utils"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+// Audit actions
+const (
+	AuditUpdateUser         = "user.update"
+	AuditForcePasswordReset = "user.force_password_reset"
+	AuditSuspendUser        = "user.suspend"
+	AuditUnsuspendUser      = "user.unsuspend"
+	AuditSetUserStatus      = "user.set_status"
+	AuditDeleteUser         = "user.delete"
+	AuditExportUser         = "user.export"
+	AuditUnlockAccount      = "user.unlock"
+	AuditGrantRole          = "role.grant"
+	AuditRevokeRole         = "role.revoke"
+)
+
+// AdminHandler serves the UserAdmin service. Every change it makes is
+// recorded in the audit trail, and no change can target a user holding a
+// role the caller lacks.
+type AdminHandler struct {
+	users *UserHandler
+	audit repository.AuditRepository
+}
+
+func NewAdminHandler(users *UserHandler, audit repository.AuditRepository) *AdminHandler {
+	return &AdminHandler{users: users, audit: audit}
+}
+
+// ListUsers finds users by part of their username or email and by status
+func (h *AdminHandler) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "admin.ListUsers")
+	defer span.Finish()
+
+	after, err := pageToken(req.GetPageToken())
+	if err != nil {
+		return nil, err
+	}
+	limit := pageSize(req.GetPageSize())
+	users, err := h.users.repo.List(ctx, repository.UserFilter{
+		Query:   req.GetQuery(),
+		Status:  req.GetStatus(),
+		AfterID: after,
+		Limit:   limit,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("failed to list users: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	resp := &pb.ListUsersResponse{Users: make([]*pb.AdminUser, 0, len(users))}
+	for _, u := range users {
+		resp.Users = append(resp.Users, u.ProtoAdminUser())
+	}
+	if len(users) == limit {
+		resp.NextPageToken = utils.UintToString(users[len(users)-1].ID)
+	}
+	return resp, nil
+}
+
+// GetUser gets any user by id
+func (h *AdminHandler) GetUser(ctx context.Context, req *pb.AdminUserRequest) (*pb.AdminUser, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "admin.GetUser")
+	defer span.Finish()
+
+	u, err := h.users.userByID(ctx, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+	return u.ProtoAdminUser(), nil
+}
+
+// UpdateUser writes the profile fields listed in the update mask. A new email
+// is taken as is, without verification; a username or email another user
+// holds is refused.
+func (h *AdminHandler) UpdateUser(ctx context.Context, req *pb.AdminUpdateUserRequest) (*pb.AdminUser, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "admin.UpdateUser")
+	defer span.Finish()
+
+	u, err := h.manageableUser(ctx, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+	paths := req.GetUpdateMask().GetPaths()
+	if len(paths) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "update_mask is required")
+	}
+	in := req.GetUser()
+	changes := make(map[string]interface{}, len(paths))
+	var columns []string
+	approveRequests := false
+	for _, path := range paths {
+		switch path {
+		case "username":
+			if other, err := h.users.repo.GetByUsername(ctx, in.GetUsername()); err == nil && other.ID != u.ID {
+				return nil, status.Error(codes.AlreadyExists, "username already exists")
+			}
+			u.Username = in.GetUsername()
+			changes[path] = u.Username
+			columns = append(columns, "username")
+		case "email":
+			if other, err := h.users.repo.GetByEmail(ctx, in.GetEmail()); err == nil && other.ID != u.ID {
+				return nil, status.Error(codes.AlreadyExists, "email already exists")
+			}
+			u.Email = in.GetEmail()
+			u.PendingEmail = ""
+			changes[path] = u.Email
+			columns = append(columns, "email", "pending_email")
+		case "email_verified":
+			if !in.GetEmailVerified() {
+				u.VerifiedAt = nil
+			} else if u.VerifiedAt == nil {
+				now := time.Now()
+				u.VerifiedAt = &now
+			}
+			changes[path] = in.GetEmailVerified()
+			columns = append(columns, "verified_at")
+		case "bio":
+			u.Bio = in.GetBio()
+			changes[path] = u.Bio
+			columns = append(columns, "bio")
+		case "image":
+			u.Image = in.GetImage()
+			changes[path] = u.Image
+			columns = append(columns, "image")
+		case "private":
+			approveRequests = u.Private && !in.GetPrivate()
+			u.Private = in.GetPrivate()
+			changes[path] = u.Private
+			columns = append(columns, "private")
+		default:
+			msg := fmt.Sprintf("unknown field in update_mask: %s", path)
+			return nil, status.Error(codes.InvalidArgument, msg)
+		}
+	}
+	if err := u.Validate(); err != nil {
+		msg := fmt.Sprintf("validation: %v", err)
+		return nil, status.Error(codes.InvalidArgument, msg)
+	}
+	if err := h.users.repo.Update(ctx, u, columns...); err != nil {
+		msg := fmt.Sprintf("failed to update: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	h.record(ctx, AuditUpdateUser, u.ID, changes)
+	if approveRequests {
+		if err := h.users.requests.ApproveAllFollowRequests(ctx, u.ID); err != nil {
+			msg := fmt.Sprintf("failed to approve follow requests: %v", err)
+			return nil, status.Error(codes.Internal, msg)
+		}
+	}
+	return u.ProtoAdminUser(), nil
+}
+
+// ForcePasswordReset signs the user out, blocks login until the password is
+// reset and mails a password reset link
+func (h *AdminHandler) ForcePasswordReset(ctx context.Context, req *pb.AdminUserRequest) (*pb.Empty, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "admin.ForcePasswordReset")
+	defer span.Finish()
+
+	u, err := h.manageableUser(ctx, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+	u.PasswordResetRequired = true
+	if err := h.users.repo.Update(ctx, u, "password_reset_required"); err != nil {
+		msg := fmt.Sprintf("failed to update: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	if err := h.users.signOut(ctx, u.ID); err != nil {
+		msg := fmt.Sprintf("failed to sign out user: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	if err := h.users.sendPasswordReset(ctx, u); err != nil {
+		return nil, err
+	}
+	h.record(ctx, AuditForcePasswordReset, u.ID, nil)
+	return &pb.Empty{}, nil
+}
+
+// SuspendUser blocks login and signs the user out of every session. A
+// suspension with an end is lifted automatically.
+func (h *AdminHandler) SuspendUser(ctx context.Context, req *pb.SuspendUserRequest) (*pb.AdminUser, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "admin.SuspendUser")
+	defer span.Finish()
+
+	return h.setStatus(ctx, AuditSuspendUser, req.GetUserId(), model.UserStatusSuspended, req.GetReason(), req.GetUntil())
+}
+
+// UnsuspendUser lets a suspended user log in again
+func (h *AdminHandler) UnsuspendUser(ctx context.Context, req *pb.AdminUserRequest) (*pb.AdminUser, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "admin.UnsuspendUser")
+	defer span.Finish()
+
+	return h.setStatus(ctx, AuditUnsuspendUser, req.GetUserId(), model.UserStatusActive, "", nil)
+}
+
+// SetUserStatus moves an account to any status
+func (h *AdminHandler) SetUserStatus(ctx context.Context, req *pb.SetUserStatusRequest) (*pb.AdminUser, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "admin.SetUserStatus")
+	defer span.Finish()
+
+	if !model.IsUserStatus(req.GetStatus()) {
+		msg := fmt.Sprintf("unknown status: %s", req.GetStatus())
+		return nil, status.Error(codes.InvalidArgument, msg)
+	}
+	return h.setStatus(ctx, AuditSetUserStatus, req.GetUserId(), req.GetStatus(), req.GetReason(), req.GetExpiresAt())
+}
+
+// setStatus changes the status of a user and signs them out when the new
+// status does not allow using the account
+func (h *AdminHandler) setStatus(ctx context.Context, action, userID, newStatus, reason string, expiresAt *timestamppb.Timestamp) (*pb.AdminUser, error) {
+	u, err := h.manageableUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	details := map[string]interface{}{
+		"from":   u.CurrentStatus(time.Now()),
+		"to":     newStatus,
+		"reason": reason,
+	}
+	var until *time.Time
+	if expiresAt != nil {
+		if err := expiresAt.CheckValid(); err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid expiry")
+		}
+		t := expiresAt.AsTime()
+		if !t.After(time.Now()) {
+			return nil, status.Error(codes.InvalidArgument, "expiry must be in the future")
+		}
+		until = &t
+		details["until"] = t
+	}
+	u.SetStatus(newStatus, reason, until)
+	if err := h.users.repo.Update(ctx, u, model.StatusColumns...); err != nil {
+		msg := fmt.Sprintf("failed to update: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	if !u.IsActive() {
+		if err := h.users.signOut(ctx, u.ID); err != nil {
+			msg := fmt.Sprintf("failed to sign out user: %v", err)
+			return nil, status.Error(codes.Internal, msg)
+		}
+	}
+	h.record(ctx, action, u.ID, details)
+	return u.ProtoAdminUser(), nil
+}
+
+// DeleteUser signs the user out and schedules the account for the next
+// purge, without a grace period
+func (h *AdminHandler) DeleteUser(ctx context.Context, req *pb.AdminUserRequest) (*pb.Empty, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "admin.DeleteUser")
+	defer span.Finish()
+
+	u, err := h.manageableUser(ctx, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	u.SetStatus(model.UserStatusPendingDeletion, "deleted by staff", &now)
+	if err := h.users.repo.Update(ctx, u, model.StatusColumns...); err != nil {
+		msg := fmt.Sprintf("failed to delete user: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	if err := h.users.signOut(ctx, u.ID); err != nil {
+		msg := fmt.Sprintf("failed to sign out user: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	h.record(ctx, AuditDeleteUser, u.ID, nil)
+	return &pb.Empty{}, nil
+}
+
+// ExportUserData streams the JSON archive of any user, to answer a data
+// subject access request on their behalf
+func (h *AdminHandler) ExportUserData(req *pb.AdminUserRequest, stream pb.UserAdmin_ExportUserDataServer) error {
+	span, ctx := opentracing.StartSpanFromContext(stream.Context(), "admin.ExportUserData")
+	defer span.Finish()
+
+	u, err := h.users.userByID(ctx, req.GetUserId())
+	if err != nil {
+		return err
+	}
+	h.record(ctx, AuditExportUser, u.ID, nil)
+	w := &chunkWriter{send: stream.Send}
+	if err := h.users.exporter.Export(ctx, u, w); err != nil {
+		msg := fmt.Sprintf("failed to export data: %v", err)
+		return status.Error(codes.Internal, msg)
+	}
+	return w.Flush()
+}
+
+// ListAuditEvents returns audit events, newest first
+func (h *AdminHandler) ListAuditEvents(ctx context.Context, req *pb.ListAuditEventsRequest) (*pb.ListAuditEventsResponse, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "admin.ListAuditEvents")
+	defer span.Finish()
+
+	filter := repository.AuditFilter{Limit: pageSize(req.GetPageSize())}
+	var err error
+	if filter.BeforeID, err = pageToken(req.GetPageToken()); err != nil {
+		return nil, err
+	}
+	if req.GetTargetUserId() != "" {
+		if filter.TargetUserID, err = utils.StringToUint(req.GetTargetUserId()); err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid target user id")
+		}
+	}
+	if req.GetActorId() != "" {
+		if filter.ActorID, err = utils.StringToUint(req.GetActorId()); err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid actor id")
+		}
+	}
+	events, err := h.audit.List(ctx, filter)
+	if err != nil {
+		msg := fmt.Sprintf("failed to list audit events: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	resp := &pb.ListAuditEventsResponse{Events: make([]*pb.AuditEvent, 0, len(events))}
+	for _, e := range events {
+		resp.Events = append(resp.Events, e.ProtoAuditEvent())
+	}
+	if len(events) == filter.Limit {
+		resp.NextPageToken = utils.UintToString(events[len(events)-1].ID)
+	}
+	return resp, nil
+}
+
+// manageableUser finds the target of a change by id. Staff can only change
+// users whose roles they all hold themselves, so e.g. only admins can
+// suspend or delete an admin.
+func (h *AdminHandler) manageableUser(ctx context.Context, id string) (*model.User, error) {
+	u, err := h.users.userByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	p, err := h.users.principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := h.users.roles.GetUserRoles(ctx, u.ID)
+	if err != nil {
+		msg := fmt.Sprintf("failed to get roles: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	for _, r := range roles {
+		if !p.HasRole(r.Name) {
+			msg := fmt.Sprintf("user has the %s role, which the caller does not have", r.Name)
+			return nil, status.Error(codes.PermissionDenied, msg)
+		}
+	}
+	return u, nil
+}
+
+// record adds an action of the caller to the audit trail. A failure is only
+// logged, the action itself already happened.
+func (h *AdminHandler) record(ctx context.Context, action string, target uint, details map[string]interface{}) {
+	event := h.users.auditEvent(ctx, action, target, details)
+	if err := h.audit.Record(ctx, event); err != nil {
+		h.users.logger.Errorf("record audit event %s on user %d: %v", action, target, err)
+	}
+}
+
+// auditEvent returns the audit event of an action of the caller
+func (h *UserHandler) auditEvent(ctx context.Context, action string, target uint, details map[string]interface{}) *model.AuditEvent {
+	event := &model.AuditEvent{Action: action, TargetUserID: target}
+	if p, err := h.principal(ctx); err == nil {
+		event.ActorID = p.UserID
+	}
+	if details != nil {
+		b, err := json.Marshal(details)
+		if err != nil {
+			h.logger.Errorf("encode audit details: %v", err)
+		} else {
+			event.Details = string(b)
+		}
+	}
+	return event
+}
+
+func pageSize(n int32) int {
+	if n <= 0 {
+		return defaultPageSize
+	}
+	if n > maxPageSize {
+		return maxPageSize
+	}
+	return int(n)
+}
+
+// pageToken decodes a page token, the id of the last item of the previous
+// page
+func pageToken(token string) (uint, error) {
+	if token == "" {
+		return 0, nil
+	}
+	id, err := utils.StringToUint(token)
+	if err != nil {
+		return 0, status.Error(codes.InvalidArgument, "invalid page token")
+	}
+	return id, nil
+}
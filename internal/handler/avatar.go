@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/opentracing/opentracing-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/rezaAmiri123/service-user/gen/pb"
+	"github.com/rezaAmiri123/service-user/internal/avatar"
+)
+
+// UploadAvatar stores the uploaded image as the avatar of the current user
+// and writes its URL to the image of the user
+func (h *UserHandler) UploadAvatar(stream pb.Users_UploadAvatarServer) error {
+	span, ctx := opentracing.StartSpanFromContext(stream.Context(), "user.UploadAvatar")
+	defer span.Finish()
+
+	u, err := h.getUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		data = append(data, chunk.GetData()...)
+		if max := h.avatars.MaxSize(); max > 0 && int64(len(data)) > max {
+			msg := fmt.Sprintf("image is larger than %d KiB", max/1024)
+			return status.Error(codes.InvalidArgument, msg)
+		}
+	}
+	if len(data) == 0 {
+		return status.Error(codes.InvalidArgument, "image is empty")
+	}
+
+	url, err := h.avatars.Save(ctx, u.ID, data)
+	switch err {
+	case nil:
+	case avatar.ErrTooLarge, avatar.ErrUnsupportedType:
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		msg := fmt.Sprintf("failed to save avatar: %v", err)
+		return status.Error(codes.Internal, msg)
+	}
+
+	u.Image = url
+	if err := h.repo.Update(ctx, u, "image"); err != nil {
+		msg := fmt.Sprintf("failed to update: %v", err)
+		return status.Error(codes.Internal, msg)
+	}
+	return stream.SendAndClose(u.ProtoUser())
+}
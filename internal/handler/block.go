@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opentracing/opentracing-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/rezaAmiri123/service-user/gen/pb"
+	"github.com/rezaAmiri123/service-user/internal/model"
+)
+
+// BlockUser blocks a user; the follows between the current user and the
+// blocked user are removed
+func (h *UserHandler) BlockUser(ctx context.Context, req *pb.BlockRequest) (*pb.Empty, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "user.BlockUser")
+	defer span.Finish()
+
+	u, err := h.getUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if u.Username == req.GetUsername() {
+		return nil, status.Error(codes.InvalidArgument, "cannot block yourself")
+	}
+	otherUser, err := h.repo.GetByUsername(ctx, req.GetUsername())
+	if err != nil {
+		msg := fmt.Sprintf("user not found: %v", err)
+		return nil, status.Error(codes.NotFound, msg)
+	}
+	if err := h.blocks.Block(ctx, u.ID, otherUser.ID); err != nil {
+		msg := fmt.Sprintf("failed to block user: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	h.suggestions.Invalidate(ctx, u.ID)
+	h.suggestions.Invalidate(ctx, otherUser.ID)
+	return &pb.Empty{}, nil
+}
+
+// UnblockUser removes a block of the current user
+func (h *UserHandler) UnblockUser(ctx context.Context, req *pb.BlockRequest) (*pb.Empty, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "user.UnblockUser")
+	defer span.Finish()
+
+	u, err := h.getUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	otherUser, err := h.repo.GetByUsername(ctx, req.GetUsername())
+	if err != nil {
+		msg := fmt.Sprintf("user not found: %v", err)
+		return nil, status.Error(codes.NotFound, msg)
+	}
+	err = h.blocks.Unblock(ctx, u.ID, otherUser.ID)
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, status.Error(codes.NotFound, "user is not blocked")
+	}
+	if err != nil {
+		msg := fmt.Sprintf("failed to unblock user: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	return &pb.Empty{}, nil
+}
+
+// ListBlocked lists the users the current user blocks
+func (h *UserHandler) ListBlocked(ctx context.Context, req *pb.ListBlockedRequest) (*pb.ProfileList, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "user.ListBlocked")
+	defer span.Finish()
+
+	u, err := h.getUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	after, err := decodeCursor(req.GetPageToken())
+	if err != nil {
+		return nil, err
+	}
+	limit := pageSize(req.GetPageSize())
+	users, err := h.blocks.ListBlocked(ctx, u.ID, after, limit)
+	if err != nil {
+		msg := fmt.Sprintf("failed to list blocked users: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	resp := &pb.ProfileList{Profiles: make([]*pb.ProfileResponse, 0, len(users))}
+	for _, b := range users {
+		resp.Profiles = append(resp.Profiles, b.ProtoProfile(pb.FollowStatus_FOLLOW_STATUS_NONE))
+	}
+	if len(users) == limit {
+		resp.NextPageToken = encodeCursor(users[len(users)-1].ID)
+	}
+	return resp, nil
+}
+
+// checkBlocked fails when either user blocks the other. A user blocked by
+// the other user gets the same error as for a missing user.
+func (h *UserHandler) checkBlocked(ctx context.Context, u, other *model.User) error {
+	blocking, blockedBy, err := h.blocks.BlockStatus(ctx, u.ID, other.ID)
+	if err != nil {
+		msg := fmt.Sprintf("failed to get block status: %v", err)
+		return status.Error(codes.Internal, msg)
+	}
+	if blockedBy {
+		return status.Error(codes.NotFound, "user not found")
+	}
+	if blocking {
+		return status.Error(codes.FailedPrecondition, "you blocked this user")
+	}
+	return nil
+}
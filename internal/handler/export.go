@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/opentracing/opentracing-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/rezaAmiri123/service-user/gen/pb"
+)
+
+// exportChunkSize keeps export messages well below the gRPC message limit
+const exportChunkSize = 64 * 1024
+
+// ExportMyData streams a JSON archive of the data kept about the current user
+func (h *UserHandler) ExportMyData(req *pb.Empty, stream pb.Users_ExportMyDataServer) error {
+	span, ctx := opentracing.StartSpanFromContext(stream.Context(), "user.ExportMyData")
+	defer span.Finish()
+
+	u, err := h.getUser(ctx)
+	if err != nil {
+		return err
+	}
+	w := &chunkWriter{send: stream.Send}
+	if err := h.exporter.Export(ctx, u, w); err != nil {
+		msg := fmt.Sprintf("failed to export data: %v", err)
+		return status.Error(codes.Internal, msg)
+	}
+	return w.Flush()
+}
+
+// chunkWriter sends what is written to it as export chunks
+type chunkWriter struct {
+	send func(*pb.ExportChunk) error
+	buf  []byte
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for len(w.buf) >= exportChunkSize {
+		if err := w.send(&pb.ExportChunk{Data: w.buf[:exportChunkSize]}); err != nil {
+			return 0, err
+		}
+		w.buf = append([]byte(nil), w.buf[exportChunkSize:]...)
+	}
+	return len(p), nil
+}
+
+// Flush sends the remaining buffered data
+func (w *chunkWriter) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	err := w.send(&pb.ExportChunk{Data: w.buf})
+	w.buf = nil
+	return err
+}
@@ -0,0 +1,339 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opentracing/opentracing-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/rezaAmiri123/service-user/gen/pb"
+	"github.com/rezaAmiri123/service-user/internal/model"
+	"github.com/rezaAmiri123/service-user/pkg/utils"
+)
+
+// cursorPrefix versions the follow list cursors
+const cursorPrefix = "f1:"
+
+// maxBatchProfiles is the number of profiles BatchGetProfiles looks up at
+// once
+const maxBatchProfiles = 100
+
+// ListFollowers lists the users following a user
+func (h *UserHandler) ListFollowers(ctx context.Context, req *pb.ListFollowsRequest) (*pb.ProfileList, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "user.ListFollowers")
+	defer span.Finish()
+
+	return h.listFollows(ctx, req, h.repo.ListFollowers)
+}
+
+// ListFollowing lists the users a user follows
+func (h *UserHandler) ListFollowing(ctx context.Context, req *pb.ListFollowsRequest) (*pb.ProfileList, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "user.ListFollowing")
+	defer span.Finish()
+
+	return h.listFollows(ctx, req, h.repo.ListFollowing)
+}
+
+type followListFunc func(ctx context.Context, userID, afterID uint, limit int) ([]*model.User, error)
+
+// listFollows returns a page of list for the user of the request. Users
+// other users cannot see are left out of the page. The lists of a private
+// account are only shown to its followers.
+func (h *UserHandler) listFollows(ctx context.Context, req *pb.ListFollowsRequest, list followListFunc) (*pb.ProfileList, error) {
+	u, err := h.getUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	target, err := h.visibleUser(ctx, req.GetUsername())
+	if err != nil {
+		return nil, err
+	}
+	if err := h.checkBlocked(ctx, u, target); err != nil {
+		return nil, err
+	}
+	if target.Private && target.ID != u.ID {
+		statuses, err := h.followStatuses(ctx, u, []*model.User{target})
+		if err != nil {
+			return nil, err
+		}
+		if statuses[target.ID] != pb.FollowStatus_FOLLOW_STATUS_FOLLOWING {
+			return nil, status.Error(codes.PermissionDenied, "account is private")
+		}
+	}
+	after, err := decodeCursor(req.GetPageToken())
+	if err != nil {
+		return nil, err
+	}
+	limit := pageSize(req.GetPageSize())
+	users, err := list(ctx, target.ID, after, limit)
+	if err != nil {
+		msg := fmt.Sprintf("failed to list follows: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+
+	statuses, err := h.followStatuses(ctx, u, users)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ProfileList{Profiles: make([]*pb.ProfileResponse, 0, len(users))}
+	for _, f := range users {
+		if !f.IsVisible() {
+			continue
+		}
+		resp.Profiles = append(resp.Profiles, f.ProtoProfile(statuses[f.ID]))
+	}
+	if len(users) == limit {
+		resp.NextPageToken = encodeCursor(users[len(users)-1].ID)
+	}
+	return resp, nil
+}
+
+// BatchGetProfiles looks up profiles by username and id with the follow
+// status of the caller, in a fixed number of queries. Hidden users and users
+// blocked either way are reported as not found.
+func (h *UserHandler) BatchGetProfiles(ctx context.Context, req *pb.BatchGetProfilesRequest) (*pb.BatchGetProfilesResponse, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "user.BatchGetProfiles")
+	defer span.Finish()
+
+	if n := len(req.GetUsernames()) + len(req.GetUserIds()); n > maxBatchProfiles {
+		msg := fmt.Sprintf("at most %d profiles can be requested, got %d", maxBatchProfiles, n)
+		return nil, status.Error(codes.InvalidArgument, msg)
+	}
+	u, err := h.getUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(req.GetUserIds()))
+	for _, s := range req.GetUserIds() {
+		id, err := utils.StringToUint(s)
+		if err != nil {
+			msg := fmt.Sprintf("invalid user id %q", s)
+			return nil, status.Error(codes.InvalidArgument, msg)
+		}
+		ids = append(ids, id)
+	}
+	byName, err := h.repo.GetByUsernames(ctx, req.GetUsernames())
+	if err != nil {
+		msg := fmt.Sprintf("failed to get users: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	byID, err := h.repo.GetByIDs(ctx, ids)
+	if err != nil {
+		msg := fmt.Sprintf("failed to get users: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+
+	users := make(map[uint]*model.User, len(byName)+len(byID))
+	for _, other := range append(byName, byID...) {
+		if other.IsVisible() {
+			users[other.ID] = other
+		}
+	}
+	// the database may compare usernames case-insensitively, so a name
+	// that only differs in case maps to the user it matched
+	usernames := make(map[string]*model.User, len(byName))
+	folded := make(map[string]*model.User, len(byName))
+	for _, other := range byName {
+		usernames[other.Username] = users[other.ID]
+		folded[strings.ToLower(other.Username)] = users[other.ID]
+	}
+	byUsername := func(name string) *model.User {
+		if other, ok := usernames[name]; ok {
+			return other
+		}
+		return folded[strings.ToLower(name)]
+	}
+	found := make([]*model.User, 0, len(users))
+	foundIDs := make([]uint, 0, len(users))
+	for id, other := range users {
+		found = append(found, other)
+		foundIDs = append(foundIDs, id)
+	}
+	blocked, err := h.blocks.BlockedAmong(ctx, u.ID, foundIDs)
+	if err != nil {
+		msg := fmt.Sprintf("failed to get block status: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	statuses, err := h.followStatuses(ctx, u, found)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.BatchGetProfilesResponse{}
+	add := func(key string, other *model.User) {
+		if other == nil || blocked[other.ID] {
+			resp.NotFound = append(resp.NotFound, key)
+			return
+		}
+		resp.Profiles = append(resp.Profiles, other.ProtoProfile(statuses[other.ID]))
+	}
+	for _, name := range req.GetUsernames() {
+		add(name, byUsername(name))
+	}
+	for i, id := range ids {
+		add(req.GetUserIds()[i], users[id])
+	}
+	return resp, nil
+}
+
+// SuggestFollows lists users the current user may want to follow
+func (h *UserHandler) SuggestFollows(ctx context.Context, req *pb.SuggestFollowsRequest) (*pb.SuggestFollowsResponse, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "user.SuggestFollows")
+	defer span.Finish()
+
+	u, err := h.getUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	suggestions, err := h.suggestions.Suggest(ctx, u.ID, int(req.GetLimit()))
+	if err != nil {
+		msg := fmt.Sprintf("failed to suggest follows: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	resp := &pb.SuggestFollowsResponse{Suggestions: make([]*pb.FollowSuggestion, 0, len(suggestions))}
+	for _, s := range suggestions {
+		resp.Suggestions = append(resp.Suggestions, &pb.FollowSuggestion{
+			Profile:       s.User.ProtoProfile(pb.FollowStatus_FOLLOW_STATUS_NONE),
+			MutualFollows: int32(s.Mutual),
+		})
+	}
+	return resp, nil
+}
+
+// ListFollowRequests lists the users asking to follow the current user
+func (h *UserHandler) ListFollowRequests(ctx context.Context, req *pb.ListFollowRequestsRequest) (*pb.ProfileList, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "user.ListFollowRequests")
+	defer span.Finish()
+
+	u, err := h.getUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	after, err := decodeCursor(req.GetPageToken())
+	if err != nil {
+		return nil, err
+	}
+	limit := pageSize(req.GetPageSize())
+	users, err := h.requests.ListFollowRequests(ctx, u.ID, after, limit)
+	if err != nil {
+		msg := fmt.Sprintf("failed to list follow requests: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	statuses, err := h.followStatuses(ctx, u, users)
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.ProfileList{Profiles: make([]*pb.ProfileResponse, 0, len(users))}
+	for _, r := range users {
+		if !r.IsVisible() {
+			continue
+		}
+		resp.Profiles = append(resp.Profiles, r.ProtoProfile(statuses[r.ID]))
+	}
+	if len(users) == limit {
+		resp.NextPageToken = encodeCursor(users[len(users)-1].ID)
+	}
+	return resp, nil
+}
+
+// ApproveFollowRequest makes the requesting user a follower of the current
+// user
+func (h *UserHandler) ApproveFollowRequest(ctx context.Context, req *pb.FollowRequestAction) (*pb.Empty, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "user.ApproveFollowRequest")
+	defer span.Finish()
+
+	return h.answerFollowRequest(ctx, req, h.requests.ApproveFollowRequest)
+}
+
+// RejectFollowRequest drops a follow request to the current user
+func (h *UserHandler) RejectFollowRequest(ctx context.Context, req *pb.FollowRequestAction) (*pb.Empty, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "user.RejectFollowRequest")
+	defer span.Finish()
+
+	return h.answerFollowRequest(ctx, req, h.requests.DeleteFollowRequest)
+}
+
+func (h *UserHandler) answerFollowRequest(
+	ctx context.Context,
+	req *pb.FollowRequestAction,
+	answer func(ctx context.Context, fromUserID, toUserID uint) error,
+) (*pb.Empty, error) {
+	u, err := h.getUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	from, err := h.repo.GetByUsername(ctx, req.GetUsername())
+	if err != nil {
+		msg := fmt.Sprintf("user not found: %v", err)
+		return nil, status.Error(codes.NotFound, msg)
+	}
+	err = answer(ctx, from.ID, u.ID)
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, status.Error(codes.NotFound, "follow request not found")
+	}
+	if err != nil {
+		msg := fmt.Sprintf("failed to answer follow request: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	h.suggestions.Invalidate(ctx, from.ID)
+	return &pb.Empty{}, nil
+}
+
+// followStatuses returns whether u follows or asked to follow each of
+// users, in two queries
+func (h *UserHandler) followStatuses(ctx context.Context, u *model.User, users []*model.User) (map[uint]pb.FollowStatus, error) {
+	ids := make([]uint, 0, len(users))
+	for _, other := range users {
+		ids = append(ids, other.ID)
+	}
+	following, err := h.repo.FollowingAmong(ctx, u.ID, ids)
+	if err != nil {
+		msg := fmt.Sprintf("failed to get follow status: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	requested, err := h.requests.RequestedAmong(ctx, u.ID, ids)
+	if err != nil {
+		msg := fmt.Sprintf("failed to get follow status: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	statuses := make(map[uint]pb.FollowStatus, len(ids))
+	for _, id := range ids {
+		switch {
+		case following[id]:
+			statuses[id] = pb.FollowStatus_FOLLOW_STATUS_FOLLOWING
+		case requested[id]:
+			statuses[id] = pb.FollowStatus_FOLLOW_STATUS_REQUESTED
+		default:
+			statuses[id] = pb.FollowStatus_FOLLOW_STATUS_NONE
+		}
+	}
+	return statuses, nil
+}
+
+// encodeCursor returns the opaque page token resuming a list after id
+func encodeCursor(id uint) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + utils.UintToString(id)))
+}
+
+// decodeCursor returns the id a page token resumes after
+func decodeCursor(token string) (uint, error) {
+	if token == "" {
+		return 0, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(b) <= len(cursorPrefix) || string(b[:len(cursorPrefix)]) != cursorPrefix {
+		return 0, status.Error(codes.InvalidArgument, "invalid page token")
+	}
+	id, err := utils.StringToUint(string(b[len(cursorPrefix):]))
+	if err != nil {
+		return 0, status.Error(codes.InvalidArgument, "invalid page token")
+	}
+	return id, nil
+}
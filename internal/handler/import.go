@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+
+	pb "github.com/rezaAmiri123/service-user/gen/pb"
+	"github.com/rezaAmiri123/service-user/internal/model"
+	"github.com/rezaAmiri123/service-user/internal/password"
+)
+
+// ImportUsers creates users migrated from another identity provider, keeping
+// their password hashes. Hashes of foreign algorithms are replaced by the
+// current algorithm on the first successful login.
+func (h *UserHandler) ImportUsers(ctx context.Context, req *pb.ImportUsersRequest) (*pb.ImportUsersResponse, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "user.ImportUsers")
+	defer span.Finish()
+
+	resp := &pb.ImportUsersResponse{}
+	fail := func(i int, email string, err error) {
+		resp.Failures = append(resp.Failures, &pb.ImportFailure{
+			Index: int32(i),
+			Email: email,
+			Error: err.Error(),
+		})
+	}
+	for i, in := range req.GetUsers() {
+		encoded, err := encodeImportedHash(in)
+		if err != nil {
+			fail(i, in.GetEmail(), err)
+			continue
+		}
+		u := &model.User{
+			Username: in.GetUsername(),
+			Email:    in.GetEmail(),
+			Password: encoded,
+			Bio:      in.GetBio(),
+			Image:    in.GetImage(),
+		}
+		if in.GetEmailVerified() {
+			now := time.Now()
+			u.VerifiedAt = &now
+		}
+		if err := u.Validate(); err != nil {
+			fail(i, in.GetEmail(), err)
+			continue
+		}
+		if err := h.repo.Create(ctx, u); err != nil {
+			fail(i, in.GetEmail(), err)
+			continue
+		}
+		resp.Imported++
+	}
+	return resp, nil
+}
+
+// encodeImportedHash returns the tagged hash stored for an imported user
+func encodeImportedHash(in *pb.ImportedUser) (string, error) {
+	var encoded string
+	switch in.GetHashAlgorithm() {
+	case pb.HashAlgorithm_HASH_ALGORITHM_BCRYPT, pb.HashAlgorithm_HASH_ALGORITHM_ARGON2ID:
+		encoded = in.GetPasswordHash()
+	case pb.HashAlgorithm_HASH_ALGORITHM_PBKDF2_SHA256:
+		salt, key, err := decodeImported(in)
+		if err != nil {
+			return "", err
+		}
+		if in.GetIterations() == 0 {
+			return "", errors.New("pbkdf2-sha256 needs iterations")
+		}
+		encoded = password.EncodePBKDF2SHA256(int(in.GetIterations()), salt, key)
+	case pb.HashAlgorithm_HASH_ALGORITHM_SCRYPT:
+		salt, key, err := decodeImported(in)
+		if err != nil {
+			return "", err
+		}
+		encoded, err = password.EncodeScrypt(int(in.GetScryptN()), int(in.GetScryptR()), int(in.GetScryptP()), salt, key)
+		if err != nil {
+			return "", err
+		}
+	case pb.HashAlgorithm_HASH_ALGORITHM_SHA512_PEPPER:
+		salt, digest, err := decodeImported(in)
+		if err != nil {
+			return "", err
+		}
+		encoded = password.EncodeSHA512(salt, digest)
+	default:
+		return "", fmt.Errorf("unsupported hash algorithm %v", in.GetHashAlgorithm())
+	}
+	if !password.Recognizes(encoded) {
+		return "", errors.New("password hash does not match its algorithm")
+	}
+	return encoded, nil
+}
+
+func decodeImported(in *pb.ImportedUser) (salt, key []byte, err error) {
+	if salt, err = decodeBase64(in.GetSalt()); err != nil {
+		return nil, nil, errors.New("salt is not base64")
+	}
+	if key, err = decodeBase64(in.GetPasswordHash()); err != nil || len(key) == 0 {
+		return nil, nil, errors.New("password hash is not base64")
+	}
+	return salt, key, nil
+}
+
+// decodeBase64 accepts standard and URL base64, with or without padding
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.TrimRight(s, "=")
+	if strings.ContainsAny(s, "-_") {
+		return base64.RawURLEncoding.DecodeString(s)
+	}
+	return base64.RawStdEncoding.DecodeString(s)
+}
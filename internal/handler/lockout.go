@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	pb "github.com/rezaAmiri123/service-user/gen/pb"
+)
+
+// UnlockAccount lifts the login lockout of an account and records it in the
+// audit trail; the lockout is only lifted when the event is stored
+func (h *UserHandler) UnlockAccount(ctx context.Context, req *pb.UnlockAccountRequest) (*pb.Empty, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "user.UnlockAccount")
+	defer span.Finish()
+
+	// the account may be locked without existing, failed logins are counted
+	// for any email
+	var target uint
+	if u, err := h.repo.GetByEmail(ctx, req.GetEmail()); err == nil {
+		target = u.ID
+	}
+	event := h.auditEvent(ctx, AuditUnlockAccount, target, map[string]interface{}{"email": req.GetEmail()})
+	err := h.audit.RecordAction(ctx, event, func() error {
+		return h.lockout.Unlock(ctx, req.GetEmail())
+	})
+	if err != nil {
+		msg := fmt.Sprintf("failed to unlock account: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	return &pb.Empty{}, nil
+}
+
+// checkLockout fails when the account or the client IP is locked. It also
+// fails when the lockout store cannot be read, rather than letting guesses
+// through unthrottled.
+func (h *UserHandler) checkLockout(ctx context.Context, account, ip string) error {
+	retry, err := h.lockout.Check(ctx, account, ip)
+	if err != nil {
+		h.logger.Errorf("check login lockout: %v", err)
+		return status.Error(codes.Unavailable, "login is temporarily unavailable")
+	}
+	if retry > 0 {
+		return lockedError(retry)
+	}
+	return nil
+}
+
+// loginFailed counts a failed login and returns the error for the caller
+func (h *UserHandler) loginFailed(ctx context.Context, account, ip string) error {
+	locked, err := h.lockout.Fail(ctx, account, ip)
+	if err != nil {
+		h.logger.Errorf("count login failure: %v", err)
+	}
+	if locked > 0 {
+		return lockedError(locked)
+	}
+	return status.Error(codes.InvalidArgument, "invalid email or password")
+}
+
+// lockedError is a ResourceExhausted error telling the client when to retry
+func lockedError(retry time.Duration) error {
+	st := status.New(codes.ResourceExhausted, "too many failed login attempts, try again later")
+	detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retry)})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// clientIP returns the IP of the caller. When the caller is a trusted
+// gateway it is the last X-Forwarded-For entry, which the gateway appends
+// itself; anyone else could send the header to pick their IP.
+func (h *UserHandler) clientIP(ctx context.Context) string {
+	ip := peerIP(ctx)
+	if !h.trustedProxy(ip) {
+		return ip
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("x-forwarded-for"); len(values) > 0 {
+			hops := strings.Split(values[len(values)-1], ",")
+			if forwarded := strings.TrimSpace(hops[len(hops)-1]); forwarded != "" {
+				return forwarded
+			}
+		}
+	}
+	return ip
+}
+
+func (h *UserHandler) trustedProxy(ip string) bool {
+	if ip == "" {
+		return false
+	}
+	for _, proxy := range h.cfg.Server.TrustedProxies {
+		if proxy == ip {
+			return true
+		}
+	}
+	return false
+}
+
+// peerIP returns the IP of the connection the request came in on
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/rezaAmiri123/service-user/gen/pb"
+	"github.com/rezaAmiri123/service-user/internal/auth"
+	"github.com/rezaAmiri123/service-user/internal/model"
+)
+
+const recoveryCodeCount = 10
+
+// EnrollTOTP creates a new TOTP secret for the user. It is not required at
+// login until confirmed with ConfirmTOTP.
+func (h *UserHandler) EnrollTOTP(ctx context.Context, req *pb.Empty) (*pb.EnrollTOTPResponse, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "user.EnrollTOTP")
+	defer span.Finish()
+
+	u, err := h.getUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if u.TOTPEnabled {
+		return nil, status.Error(codes.FailedPrecondition, "two-factor authentication is already enabled")
+	}
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		msg := fmt.Sprintf("failed to create secret: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	sealed, err := auth.EncryptTOTPSecret(secret)
+	if err != nil {
+		msg := fmt.Sprintf("failed to encrypt secret: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	u.TOTPSecret = sealed
+	if err := h.repo.Update(ctx, u, "totp_secret"); err != nil {
+		msg := fmt.Sprintf("failed to update: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	issuer := h.cfg.Common.TOTPIssuer
+	if issuer == "" {
+		issuer = "service-user"
+	}
+	return &pb.EnrollTOTPResponse{
+		Secret:     secret,
+		OtpauthUri: auth.TOTPURI(issuer, u.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTP enables two-factor authentication after checking a code from
+// the enrolled authenticator, and returns one-time recovery codes
+func (h *UserHandler) ConfirmTOTP(ctx context.Context, req *pb.ConfirmTOTPRequest) (*pb.ConfirmTOTPResponse, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "user.ConfirmTOTP")
+	defer span.Finish()
+
+	u, err := h.getUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if u.TOTPEnabled {
+		return nil, status.Error(codes.FailedPrecondition, "two-factor authentication is already enabled")
+	}
+	secret, err := h.totpSecret(ctx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+	if secret == "" {
+		return nil, status.Error(codes.FailedPrecondition, "two-factor authentication is not enrolled")
+	}
+	step, ok := auth.ValidateTOTP(secret, req.GetCode(), time.Now())
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, "invalid code")
+	}
+
+	plain, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		msg := fmt.Sprintf("failed to create recovery codes: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	recoveryCodes := make([]*model.RecoveryCode, len(plain))
+	for i, c := range plain {
+		recoveryCodes[i] = &model.RecoveryCode{CodeHash: auth.HashToken(c)}
+	}
+	if err := h.tokenRepo.ReplaceRecoveryCodes(ctx, u.ID, recoveryCodes); err != nil {
+		msg := fmt.Sprintf("failed to store recovery codes: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+
+	u.TOTPEnabled = true
+	u.TOTPLastStep = step
+	if err := h.repo.Update(ctx, u, "totp_enabled", "totp_last_step"); err != nil {
+		msg := fmt.Sprintf("failed to update: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	return &pb.ConfirmTOTPResponse{RecoveryCodes: plain}, nil
+}
+
+// VerifyMFA finishes a login that returned an MFA challenge, with either a
+// TOTP code or a recovery code
+func (h *UserHandler) VerifyMFA(ctx context.Context, req *pb.VerifyMFARequest) (*pb.LoginResponse, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "user.VerifyMFA")
+	defer span.Finish()
+
+	claims, err := auth.ParseMFAChallenge(req.GetMfaToken())
+	if err != nil {
+		msg := fmt.Sprintf("unauthenticated: %v", err)
+		return nil, status.Error(codes.Unauthenticated, msg)
+	}
+	u, err := h.repo.GetByID(ctx, claims.UserID)
+	if err != nil || !u.TOTPEnabled {
+		return nil, status.Error(codes.Unauthenticated, "invalid mfa challenge")
+	}
+	// second factor guesses count towards the same lockout as passwords
+	ip := h.clientIP(ctx)
+	if err := h.checkLockout(ctx, u.Email, ip); err != nil {
+		return nil, err
+	}
+
+	if req.GetRecoveryCode() != "" {
+		hash := auth.HashToken(auth.NormalizeRecoveryCode(req.GetRecoveryCode()))
+		if err := h.tokenRepo.UseRecoveryCode(ctx, u.ID, hash); err != nil {
+			return nil, h.loginFailed(ctx, u.Email, ip)
+		}
+		return h.login(ctx, u)
+	}
+
+	secret, err := h.totpSecret(ctx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+	step, ok := auth.ValidateTOTP(secret, req.GetCode(), time.Now())
+	if !ok {
+		return nil, h.loginFailed(ctx, u.Email, ip)
+	}
+	// a code is accepted once, even when requests race with it
+	used, err := h.repo.UseTOTPStep(ctx, u.ID, step)
+	if err != nil {
+		msg := fmt.Sprintf("failed to update: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	if !used {
+		return nil, h.loginFailed(ctx, u.Email, ip)
+	}
+	u.TOTPLastStep = step
+	return h.login(ctx, u)
+}
+
+// totpSecret loads and decrypts the TOTP secret of a user, empty when the
+// user never enrolled
+func (h *UserHandler) totpSecret(ctx context.Context, userID uint) (string, error) {
+	sealed, err := h.repo.GetTOTPSecret(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("failed to load secret: %v", err)
+		return "", status.Error(codes.Internal, msg)
+	}
+	if sealed == "" {
+		return "", nil
+	}
+	secret, err := auth.DecryptTOTPSecret(sealed)
+	if err != nil {
+		msg := fmt.Sprintf("failed to decrypt secret: %v", err)
+		return "", status.Error(codes.Internal, msg)
+	}
+	return secret, nil
+}
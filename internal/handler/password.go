@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/rezaAmiri123/service-user/gen/pb"
+	"github.com/rezaAmiri123/service-user/internal/auth"
+	"github.com/rezaAmiri123/service-user/internal/model"
+	"github.com/rezaAmiri123/service-user/pkg/mail"
+)
+
+// RequestPasswordReset mails a password reset link to the user. The response
+// is the same whether or not the email belongs to a user.
+func (h *UserHandler) RequestPasswordReset(ctx context.Context, req *pb.PasswordResetRequest) (*pb.Empty, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "user.RequestPasswordReset")
+	defer span.Finish()
+
+	user, err := h.repo.GetByEmail(ctx, req.GetEmail())
+	if err != nil {
+		h.logger.Debugf("password reset for unknown email: %v", err)
+		return &pb.Empty{}, nil
+	}
+	if err := h.sendPasswordReset(ctx, user); err != nil {
+		return nil, err
+	}
+	return &pb.Empty{}, nil
+}
+
+// sendPasswordReset creates a password reset token for the user and mails
+// it in the background, so the response time does not tell whether the
+// email exists
+func (h *UserHandler) sendPasswordReset(ctx context.Context, user *model.User) error {
+	token, hash, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		msg := fmt.Sprintf("failed to create reset token: %v", err)
+		return status.Error(codes.Internal, msg)
+	}
+	err = h.tokenRepo.CreatePasswordResetToken(ctx, &model.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(auth.PasswordResetDuration),
+	})
+	if err != nil {
+		msg := fmt.Sprintf("failed to store reset token: %v", err)
+		return status.Error(codes.Internal, msg)
+	}
+
+	msg := &mail.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Use the link below to choose a new password. It expires in %v.\n\n%s?token=%s\n",
+			auth.PasswordResetDuration,
+			h.cfg.Mail.ResetURL,
+			url.QueryEscape(token),
+		),
+	}
+	go func() {
+		if err := h.mailer.Send(context.Background(), msg); err != nil {
+			h.logger.Errorf("send password reset mail: %v", err)
+		}
+	}()
+	return nil
+}
+
+// ResetPassword sets a new password using a password reset token and signs
+// the user out of every session
+func (h *UserHandler) ResetPassword(ctx context.Context, req *pb.ResetPasswordRequest) (*pb.Empty, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "user.ResetPassword")
+	defer span.Finish()
+
+	t, err := h.tokenRepo.GetPasswordResetToken(ctx, auth.HashToken(req.GetToken()))
+	if err != nil || t.UsedAt != nil || t.IsExpired() {
+		return nil, status.Error(codes.InvalidArgument, "invalid or expired reset token")
+	}
+	u, err := h.repo.GetByID(ctx, t.UserID)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid or expired reset token")
+	}
+	if err := h.checkPassword(req.GetNewPassword(), u); err != nil {
+		return nil, err
+	}
+	u.Password = req.GetNewPassword()
+	if err := u.HashPassword(); err != nil {
+		msg := fmt.Sprintf("failed to hash password: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	u.PasswordResetRequired = false
+	if err := h.tokenRepo.UsePasswordResetToken(ctx, t); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid or expired reset token")
+	}
+	if err := h.repo.Update(ctx, u, "password", "password_reset_required"); err != nil {
+		msg := fmt.Sprintf("failed to update: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	if err := h.signOut(ctx, u.ID); err != nil {
+		h.logger.Errorf("sign out after password reset: %v", err)
+	}
+	return &pb.Empty{}, nil
+}
+
+// checkPassword validates a new password of the user against the password
+// policy. Violations are returned as BadRequest field violations.
+func (h *UserHandler) checkPassword(plain string, u *model.User) error {
+	violations := h.passwords.Validate(plain, u.Username, u.Email)
+	if len(violations) == 0 {
+		return nil
+	}
+	descriptions := make([]string, len(violations))
+	details := &errdetails.BadRequest{}
+	for i, v := range violations {
+		descriptions[i] = v.Description
+		details.FieldViolations = append(details.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       "password",
+			Description: v.Rule + ": " + v.Description,
+		})
+	}
+	st := status.New(codes.InvalidArgument, "password "+strings.Join(descriptions, ", "))
+	detailed, err := st.WithDetails(details)
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
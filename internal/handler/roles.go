@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opentracing/opentracing-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/rezaAmiri123/service-user/gen/pb"
+	"github.com/rezaAmiri123/service-user/internal/model"
+	"github.com/rezaAmiri123/service-user/pkg/utils"
+)
+
+// GrantRole gives a role to a user and records it in the audit trail. It
+// applies to the access tokens the user gets from the next login or refresh.
+func (h *UserHandler) GrantRole(ctx context.Context, req *pb.RoleRequest) (*pb.UserRolesResponse, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "user.GrantRole")
+	defer span.Finish()
+
+	u, err := h.userByID(ctx, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+	event := h.auditEvent(ctx, AuditGrantRole, u.ID, map[string]interface{}{"role": req.GetRole()})
+	if err := h.roles.GrantRole(ctx, u.ID, req.GetRole(), event); err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, status.Error(codes.NotFound, "role not found")
+		}
+		msg := fmt.Sprintf("failed to grant role: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	return h.userRoles(ctx, u)
+}
+
+// RevokeRole takes a role away from a user, records it in the audit trail
+// and signs the user out, so no access token with the role stays valid
+func (h *UserHandler) RevokeRole(ctx context.Context, req *pb.RoleRequest) (*pb.UserRolesResponse, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "user.RevokeRole")
+	defer span.Finish()
+
+	u, err := h.userByID(ctx, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+	event := h.auditEvent(ctx, AuditRevokeRole, u.ID, map[string]interface{}{"role": req.GetRole()})
+	if err := h.roles.RevokeRole(ctx, u.ID, req.GetRole(), event); err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, status.Error(codes.NotFound, "role not found")
+		}
+		msg := fmt.Sprintf("failed to revoke role: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	// the role is baked into the user's access tokens, which are only
+	// rejected once their session is revoked
+	if err := h.signOut(ctx, u.ID); err != nil {
+		msg := fmt.Sprintf("failed to sign out user: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	return h.userRoles(ctx, u)
+}
+
+func (h *UserHandler) userRoles(ctx context.Context, u *model.User) (*pb.UserRolesResponse, error) {
+	roles, err := h.roles.GetUserRoles(ctx, u.ID)
+	if err != nil {
+		msg := fmt.Sprintf("failed to get roles: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	return &pb.UserRolesResponse{
+		UserId:      utils.UintToString(u.ID),
+		Roles:       model.RoleNames(roles),
+		Permissions: model.PermissionNames(roles),
+	}, nil
+}
+
+// userByID finds the user of an id received in a request
+func (h *UserHandler) userByID(ctx context.Context, id string) (*model.User, error) {
+	uid, err := utils.StringToUint(id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user id")
+	}
+	u, err := h.repo.GetByID(ctx, uid)
+	if err != nil {
+		msg := fmt.Sprintf("user not found: %v", err)
+		return nil, status.Error(codes.NotFound, msg)
+	}
+	return u, nil
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opentracing/opentracing-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/rezaAmiri123/service-user/gen/pb"
+	"github.com/rezaAmiri123/service-user/internal/auth"
+)
+
+// ListSessions returns the active sessions of the current user
+func (h *UserHandler) ListSessions(ctx context.Context, req *pb.Empty) (*pb.SessionList, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "user.ListSessions")
+	defer span.Finish()
+
+	principal, err := h.principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := h.sessions.ListSessions(ctx, principal.UserID)
+	if err != nil {
+		msg := fmt.Sprintf("failed to list sessions: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	list := &pb.SessionList{Sessions: make([]*pb.Session, 0, len(sessions))}
+	for _, s := range sessions {
+		list.Sessions = append(list.Sessions, s.ProtoSession(s.SessionID == principal.SessionID))
+	}
+	return list, nil
+}
+
+// RevokeSession signs the current user out of one of their sessions
+func (h *UserHandler) RevokeSession(ctx context.Context, req *pb.RevokeSessionRequest) (*pb.Empty, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "user.RevokeSession")
+	defer span.Finish()
+
+	principal, err := h.principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.revokeSession(ctx, principal.UserID, req.GetId()); err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, status.Error(codes.NotFound, "session not found")
+		}
+		msg := fmt.Sprintf("failed to revoke session: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	return &pb.Empty{}, nil
+}
+
+// RevokeAllOtherSessions signs the current user out everywhere except in the
+// session of the request
+func (h *UserHandler) RevokeAllOtherSessions(ctx context.Context, req *pb.Empty) (*pb.Empty, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "user.RevokeAllOtherSessions")
+	defer span.Finish()
+
+	principal, err := h.principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.revokeOtherSessions(ctx, principal); err != nil {
+		return nil, err
+	}
+	return &pb.Empty{}, nil
+}
+
+// revokeOtherSessions revokes every session of the principal's user except
+// the one of the request, and their refresh tokens
+func (h *UserHandler) revokeOtherSessions(ctx context.Context, principal *auth.Principal) error {
+	revoked, err := h.sessions.RevokeOtherSessions(ctx, principal.UserID, principal.SessionID)
+	if err != nil {
+		msg := fmt.Sprintf("failed to revoke sessions: %v", err)
+		return status.Error(codes.Internal, msg)
+	}
+	for _, id := range revoked {
+		if err := h.tokenRepo.RevokeTokenFamily(ctx, id); err != nil {
+			msg := fmt.Sprintf("failed to revoke refresh tokens: %v", err)
+			return status.Error(codes.Internal, msg)
+		}
+	}
+	return nil
+}
+
+// revokeSession revokes a session of a user and its refresh tokens. Its
+// access tokens are rejected from then on because their session is no
+// longer active.
+func (h *UserHandler) revokeSession(ctx context.Context, userID uint, sessionID string) error {
+	if err := h.sessions.RevokeSession(ctx, userID, sessionID); err != nil {
+		return err
+	}
+	return h.tokenRepo.RevokeTokenFamily(ctx, sessionID)
+}
+
+// signOut revokes every session and refresh token of a user
+func (h *UserHandler) signOut(ctx context.Context, userID uint) error {
+	if err := h.tokenRepo.RevokeUserTokens(ctx, userID); err != nil {
+		return err
+	}
+	return h.sessions.RevokeUserSessions(ctx, userID)
+}
+
+// userAgent returns the user agent of the caller; calls through the gateway
+// carry the user agent of the HTTP client
+func userAgent(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	for _, key := range []string{"grpcgateway-user-agent", "user-agent"} {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/rezaAmiri123/service-user/internal/model"
+)
+
+// checkStatus fails when the status of the account does not allow login.
+// A timed suspension that has expired is lifted. An account pending
+// deletion passes and is restored by login once every login step passed.
+func (h *UserHandler) checkStatus(ctx context.Context, u *model.User) error {
+	now := time.Now()
+	switch u.CurrentStatus(now) {
+	case model.UserStatusSuspended:
+		msg := "account is suspended"
+		if u.StatusExpiresAt != nil {
+			msg += " until " + u.StatusExpiresAt.UTC().Format(time.RFC3339)
+		}
+		return status.Error(codes.PermissionDenied, msg)
+	case model.UserStatusDeactivated:
+		return status.Error(codes.PermissionDenied, "account is deactivated")
+	case model.UserStatusPendingVerification:
+		return status.Error(codes.FailedPrecondition, "email is not verified")
+	case model.UserStatusPendingDeletion:
+		if u.StatusExpiresAt != nil && !now.Before(*u.StatusExpiresAt) {
+			return status.Error(codes.PermissionDenied, "account is deleted")
+		}
+		return nil
+	case model.UserStatusDeleted:
+		return status.Error(codes.PermissionDenied, "account is deleted")
+	}
+	if u.Status != model.UserStatusActive {
+		u.SetStatus(model.UserStatusActive, "", nil)
+		if err := h.repo.Update(ctx, u, model.StatusColumns...); err != nil {
+			h.logger.Errorf("reactivate account: %v", err)
+		}
+	}
+	return nil
+}
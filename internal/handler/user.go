@@ -2,26 +2,85 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/jinzhu/gorm"
 	"github.com/opentracing/opentracing-go"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	"github.com/rezaAmiri123/service-user/cmd/config"
 	pb "github.com/rezaAmiri123/service-user/gen/pb"
 	"github.com/rezaAmiri123/service-user/internal/auth"
+	"github.com/rezaAmiri123/service-user/internal/avatar"
+	"github.com/rezaAmiri123/service-user/internal/export"
+	"github.com/rezaAmiri123/service-user/internal/lockout"
 	"github.com/rezaAmiri123/service-user/internal/model"
+	"github.com/rezaAmiri123/service-user/internal/password"
 	"github.com/rezaAmiri123/service-user/internal/repository"
+	"github.com/rezaAmiri123/service-user/internal/suggest"
+	"github.com/rezaAmiri123/service-user/pkg/grpc_errors"
 	"github.com/rezaAmiri123/service-user/pkg/logger"
+	"github.com/rezaAmiri123/service-user/pkg/mail"
 )
 
 type UserHandler struct {
-	repo   repository.UserRepository
-	logger logger.Logger
+	repo        repository.UserRepository
+	tokenRepo   repository.TokenRepository
+	sessions    repository.SessionRepository
+	roles       repository.RoleRepository
+	audit       repository.AuditRepository
+	blocks      repository.BlockRepository
+	requests    repository.FollowRequestRepository
+	revocations repository.RevocationRepository
+	lockout     *lockout.Guard
+	passwords   *password.Policy
+	mailer      mail.Sender
+	exporter    *export.Exporter
+	avatars     *avatar.Service
+	suggestions *suggest.Service
+	cfg         *config.Config
+	logger      logger.Logger
 }
 
-func NewUserHandler(repo repository.UserRepository, logger logger.Logger) *UserHandler {
-	return &UserHandler{repo: repo, logger: logger}
+func NewUserHandler(
+	repo repository.UserRepository,
+	tokenRepo repository.TokenRepository,
+	sessions repository.SessionRepository,
+	roles repository.RoleRepository,
+	audit repository.AuditRepository,
+	blocks repository.BlockRepository,
+	requests repository.FollowRequestRepository,
+	revocations repository.RevocationRepository,
+	lockout *lockout.Guard,
+	passwords *password.Policy,
+	mailer mail.Sender,
+	exporter *export.Exporter,
+	avatars *avatar.Service,
+	suggestions *suggest.Service,
+	cfg *config.Config,
+	logger logger.Logger,
+) *UserHandler {
+	return &UserHandler{
+		repo:        repo,
+		tokenRepo:   tokenRepo,
+		sessions:    sessions,
+		roles:       roles,
+		audit:       audit,
+		blocks:      blocks,
+		requests:    requests,
+		revocations: revocations,
+		lockout:     lockout,
+		passwords:   passwords,
+		mailer:      mailer,
+		exporter:    exporter,
+		avatars:     avatars,
+		suggestions: suggestions,
+		cfg:         cfg,
+		logger:      logger,
+	}
 }
 
 func (h *UserHandler) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.UserResponse, error) {
@@ -32,11 +91,18 @@ func (h *UserHandler) CreateUser(ctx context.Context, req *pb.CreateUserRequest)
 		Username: req.GetUsername(),
 		Email:    req.GetEmail(),
 		Password: req.GetPassword(),
+		Status:   model.UserStatusActive,
+	}
+	if h.cfg.Common.RequireVerifiedEmail {
+		u.Status = model.UserStatusPendingVerification
 	}
 	if err := u.Validate(); err != nil {
 		h.logger.Errorf("validate: %v", err)
 		return nil, status.Errorf(codes.InvalidArgument, "validate %v", err)
 	}
+	if err := h.checkPassword(u.Password, u); err != nil {
+		return nil, err
+	}
 	if err := u.HashPassword(); err != nil {
 		msg := fmt.Sprintf("failed to hash password: %w", err.Error())
 		return nil, status.Error(codes.Aborted, msg)
@@ -45,6 +111,7 @@ func (h *UserHandler) CreateUser(ctx context.Context, req *pb.CreateUserRequest)
 		msg := fmt.Sprintf("failed to create user: %w", err.Error())
 		return nil, status.Error(codes.Canceled, msg)
 	}
+	h.sendVerification(u, u.Email)
 	return u.ProtoUser(), nil
 }
 
@@ -53,21 +120,183 @@ func (h *UserHandler) LoginUser(ctx context.Context, req *pb.LoginRequest) (*pb.
 	span, ctx := opentracing.StartSpanFromContext(ctx, "user.Login")
 	defer span.Finish()
 
+	ip := h.clientIP(ctx)
+	if err := h.checkLockout(ctx, req.GetEmail(), ip); err != nil {
+		return nil, err
+	}
+	if err := h.lockout.Delay(ctx, req.GetEmail()); err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+
 	user, err := h.repo.GetByEmail(ctx, req.GetEmail())
 	if err != nil {
-		msg := fmt.Sprintf("invalid email or password: %w", err.Error())
-		return nil, status.Error(codes.InvalidArgument, msg)
+		return nil, h.loginFailed(ctx, req.GetEmail(), ip)
 	}
-	if !user.CheckPassword(req.GetPassword()) {
-		msg := fmt.Sprintf("invalid email or password: %w", err.Error())
-		return nil, status.Error(codes.InvalidArgument, msg)
+	ok, rehashed := user.UpgradePassword(req.GetPassword())
+	if !ok {
+		return nil, h.loginFailed(ctx, req.GetEmail(), ip)
+	}
+	if rehashed {
+		if err := h.repo.Update(ctx, user, "password"); err != nil {
+			h.logger.Errorf("store upgraded password hash: %v", err)
+		}
+	}
+	if err := h.lockout.Succeed(ctx, req.GetEmail()); err != nil {
+		h.logger.Errorf("reset login failures: %v", err)
+	}
+	if err := h.checkSignIn(ctx, user); err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		mfaToken, err := auth.GenerateMFAChallenge(user.ID)
+		if err != nil {
+			msg := fmt.Sprintf("failed to create token: %v", err)
+			return nil, status.Error(codes.Internal, msg)
+		}
+		return &pb.LoginResponse{MfaRequired: true, MfaToken: mfaToken}, nil
+	}
+	return h.login(ctx, user)
+}
+
+// checkSignIn fails when the user may not get tokens, on login as well as
+// on refresh
+func (h *UserHandler) checkSignIn(ctx context.Context, user *model.User) error {
+	if err := h.checkStatus(ctx, user); err != nil {
+		return err
+	}
+	if user.PasswordResetRequired {
+		return status.Error(codes.FailedPrecondition, "password reset required")
+	}
+	if h.cfg.Common.RequireVerifiedEmail && !user.IsVerified() {
+		return status.Error(codes.FailedPrecondition, "email is not verified")
 	}
-	token, err := auth.GenerateToken(user.ID)
+	return nil
+}
+
+// login starts a new session and token family for a user who passed every
+// login step. It restores an account pending deletion.
+func (h *UserHandler) login(ctx context.Context, user *model.User) (*pb.LoginResponse, error) {
+	restored := false
+	if user.Status == model.UserStatusPendingDeletion {
+		user.SetStatus(model.UserStatusActive, "", nil)
+		if err := h.repo.Update(ctx, user, model.StatusColumns...); err != nil {
+			msg := fmt.Sprintf("failed to restore account: %v", err)
+			return nil, status.Error(codes.Internal, msg)
+		}
+		restored = true
+	}
+	familyID, err := auth.NewTokenFamily()
 	if err != nil {
-		msg := fmt.Sprintf("failed to create token: %w", err.Error())
-		return nil, status.Error(codes.InvalidArgument, msg)
+		msg := fmt.Sprintf("failed to create token: %v", err)
+		return nil, status.Error(codes.Internal, msg)
 	}
-	return &pb.LoginResponse{Token: token}, nil
+	session := &model.Session{
+		UserID:    user.ID,
+		SessionID: familyID,
+		UserAgent: userAgent(ctx),
+		IP:        h.clientIP(ctx),
+		ExpiresAt: time.Now().Add(auth.RefreshTokenDuration),
+	}
+	if err := h.sessions.CreateSession(ctx, session); err != nil {
+		msg := fmt.Sprintf("failed to create session: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	resp, err := h.issueTokens(ctx, user.ID, familyID, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.AccountRestored = restored
+	return resp, nil
+}
+
+// RefreshToken rotates a refresh token and issues a new access token, when
+// the user could still log in. Presenting an already rotated token revokes
+// its whole family.
+func (h *UserHandler) RefreshToken(ctx context.Context, req *pb.RefreshTokenRequest) (*pb.LoginResponse, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "user.RefreshToken")
+	defer span.Finish()
+
+	old, err := h.tokenRepo.GetRefreshToken(ctx, auth.HashToken(req.GetRefreshToken()))
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid refresh token")
+	}
+	if old.RevokedAt != nil || old.IsExpired() {
+		return nil, status.Error(codes.Unauthenticated, "invalid refresh token")
+	}
+	if old.RotatedAt != nil {
+		return nil, h.revokeReusedFamily(ctx, old)
+	}
+	user, err := h.repo.GetByID(ctx, old.UserID)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid refresh token")
+	}
+	if err := h.checkSignIn(ctx, user); err != nil {
+		return nil, err
+	}
+	resp, err := h.issueTokens(ctx, old.UserID, old.FamilyID, old)
+	if errors.Is(err, grpc_errors.ErrRefreshTokenReused) {
+		return nil, h.revokeReusedFamily(ctx, old)
+	}
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(auth.RefreshTokenDuration)
+	if err := h.sessions.TouchSession(ctx, old.FamilyID, h.clientIP(ctx), expiresAt); err != nil {
+		h.logger.Errorf("update session last seen: %v", err)
+	}
+	return resp, nil
+}
+
+// Logout revokes the access token of the request, its session and the
+// refresh tokens issued with it
+func (h *UserHandler) Logout(ctx context.Context, req *pb.Empty) (*pb.Empty, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "user.Logout")
+	defer span.Finish()
+
+	principal, err := h.principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ttl := time.Until(time.Unix(principal.ExpiresAt, 0))
+	if err := h.revocations.Revoke(ctx, principal.TokenID, ttl); err != nil {
+		msg := fmt.Sprintf("failed to revoke token: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	if principal.SessionID != "" {
+		if err := h.tokenRepo.RevokeTokenFamily(ctx, principal.SessionID); err != nil {
+			msg := fmt.Sprintf("failed to revoke refresh tokens: %v", err)
+			return nil, status.Error(codes.Internal, msg)
+		}
+		err := h.sessions.RevokeSession(ctx, principal.UserID, principal.SessionID)
+		if err != nil && !gorm.IsRecordNotFoundError(err) {
+			msg := fmt.Sprintf("failed to revoke session: %v", err)
+			return nil, status.Error(codes.Internal, msg)
+		}
+	}
+	return &pb.Empty{}, nil
+}
+
+// GetJWKS returns the public keys that verify access tokens
+func (h *UserHandler) GetJWKS(ctx context.Context, req *pb.Empty) (*pb.JWKSet, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "user.GetJWKS")
+	defer span.Finish()
+
+	keys := auth.JWKS()
+	set := &pb.JWKSet{Keys: make([]*pb.JWK, 0, len(keys))}
+	for _, k := range keys {
+		set.Keys = append(set.Keys, &pb.JWK{
+			Kty: k.Kty,
+			Kid: k.Kid,
+			Use: k.Use,
+			Alg: k.Alg,
+			N:   k.N,
+			E:   k.E,
+			Crv: k.Crv,
+			X:   k.X,
+			Y:   k.Y,
+		})
+	}
+	return set, nil
 }
 
 // GetUser gets current user
@@ -82,6 +311,10 @@ func (h *UserHandler) GetUser(ctx context.Context, req *pb.Empty) (*pb.UserRespo
 	return u.ProtoUser(), nil
 }
 
+// UpdateUser changes the fields of the current user listed in the update
+// mask; an empty bio or image in the mask clears it. Without a mask only
+// the non-empty fields are changed and private is left as is. Changing the
+// password takes the current one and signs out the other sessions.
 func (h *UserHandler) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.UserResponse, error) {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "user.UpdateUser")
 	defer span.Finish()
@@ -90,21 +323,65 @@ func (h *UserHandler) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest)
 	if err != nil {
 		return nil, err
 	}
-	// update non zero-valu fields eonly
-	username := req.GetUsername()
-	if username != "" {
-		u.Username = username
+	fields, err := updateUserFields(req)
+	if err != nil {
+		return nil, err
 	}
 
-	email := req.GetEmail()
-	if email != "" {
-		u.Email = email
+	var columns []string
+	if fields["username"] {
+		u.Username = req.GetUsername()
+		columns = append(columns, "username")
+	}
+	if fields["bio"] {
+		u.Bio = req.GetBio()
+		columns = append(columns, "bio")
+	}
+	if fields["image"] {
+		u.Image = req.GetImage()
+		columns = append(columns, "image")
+	}
+	approveRequests := false
+	if fields["private"] {
+		approveRequests = u.Private && !req.GetPrivate()
+		u.Private = req.GetPrivate()
+		columns = append(columns, "private")
+	}
+
+	// a new email only replaces the current one once it is verified
+	verifyEmail := false
+	if fields["email"] {
+		email := req.GetEmail()
+		if email == "" {
+			return nil, status.Error(codes.InvalidArgument, "email cannot be cleared")
+		}
+		if email == u.Email {
+			u.PendingEmail = ""
+		} else {
+			u.PendingEmail = email
+			verifyEmail = true
+		}
+		columns = append(columns, "pending_email")
 	}
 
-	password := req.GetPassword()
-	if password != "" {
+	// checked last, the password must not contain the new username
+	if fields["password"] {
+		password := req.GetPassword()
+		if password == "" {
+			return nil, status.Error(codes.InvalidArgument, "password cannot be cleared")
+		}
+		if !u.CheckPassword(req.GetCurrentPassword()) {
+			return nil, status.Error(codes.PermissionDenied, "wrong password")
+		}
+		if err := h.checkPassword(password, u); err != nil {
+			return nil, err
+		}
 		u.Password = password
-		u.HashPassword()
+		if err := u.HashPassword(); err != nil {
+			msg := fmt.Sprintf("failed to hash password: %v", err)
+			return nil, status.Error(codes.Internal, msg)
+		}
+		columns = append(columns, "password")
 	}
 
 	if err := u.Validate(); err != nil {
@@ -112,13 +389,60 @@ func (h *UserHandler) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest)
 		return nil, status.Error(codes.InvalidArgument, msg)
 	}
 
-	if err := h.repo.Update(ctx, u); err != nil {
+	if err := h.repo.Update(ctx, u, columns...); err != nil {
 		msg := fmt.Sprintf("failed to update: %w", err.Error())
 		return nil, status.Error(codes.InvalidArgument, msg)
 	}
+	// whoever knew the old password is signed out everywhere else
+	if fields["password"] {
+		principal, err := h.principal(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := h.revokeOtherSessions(ctx, principal); err != nil {
+			return nil, err
+		}
+	}
+	if verifyEmail {
+		h.sendVerification(u, u.PendingEmail)
+	}
+	if approveRequests {
+		if err := h.requests.ApproveAllFollowRequests(ctx, u.ID); err != nil {
+			msg := fmt.Sprintf("failed to approve follow requests: %v", err)
+			return nil, status.Error(codes.Internal, msg)
+		}
+	}
 	return u.ProtoUser(), nil
 }
 
+// updateUserFields returns the fields an UpdateUser request changes: the
+// paths of its update mask, or its non-empty fields other than private when
+// it has no mask
+func updateUserFields(req *pb.UpdateUserRequest) (map[string]bool, error) {
+	fields := make(map[string]bool)
+	paths := req.GetUpdateMask().GetPaths()
+	if len(paths) == 0 {
+		fields["username"] = req.GetUsername() != ""
+		fields["email"] = req.GetEmail() != ""
+		fields["password"] = req.GetPassword() != ""
+		fields["bio"] = req.GetBio() != ""
+		fields["image"] = req.GetImage() != ""
+		// a false private cannot be told apart from an unset one, so
+		// private is only written through the mask
+		return fields, nil
+	}
+	for _, path := range paths {
+		switch path {
+		case "username", "email", "password", "bio", "image", "private":
+			fields[path] = true
+		default:
+			msg := fmt.Sprintf("unknown field in update_mask: %s", path)
+			return nil, status.Error(codes.InvalidArgument, msg)
+		}
+	}
+	return fields, nil
+}
+
 func (h *UserHandler) GetProfile(ctx context.Context, req *pb.ProfileRequest) (*pb.ProfileResponse, error) {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "user.GetProfile")
 	defer span.Finish()
@@ -127,17 +451,18 @@ func (h *UserHandler) GetProfile(ctx context.Context, req *pb.ProfileRequest) (*
 	if err != nil {
 		return nil, err
 	}
-	otherUser, err := h.repo.GetByUsername(ctx, req.GetUsername())
+	otherUser, err := h.visibleUser(ctx, req.GetUsername())
 	if err != nil {
-		msg := fmt.Sprintf("user not found: %w", err.Error())
-		return nil, status.Error(codes.NotFound, msg)
+		return nil, err
 	}
-	isFollowing, err := h.repo.IsFollowing(ctx, u, otherUser)
+	if err := h.checkBlocked(ctx, u, otherUser); err != nil {
+		return nil, err
+	}
+	statuses, err := h.followStatuses(ctx, u, []*model.User{otherUser})
 	if err != nil {
-		msg := fmt.Sprintf("failed to get follow status: %w", err.Error())
-		return nil, status.Error(codes.NotFound, msg)
+		return nil, err
 	}
-	return u.ProtoProfile(isFollowing), nil
+	return otherUser.ProtoProfile(statuses[otherUser.ID]), nil
 }
 
 func (h *UserHandler) FollowUser(ctx context.Context, req *pb.FollowRequest) (*pb.ProfileResponse, error) {
@@ -149,19 +474,40 @@ func (h *UserHandler) FollowUser(ctx context.Context, req *pb.FollowRequest) (*p
 		return nil, err
 	}
 	if u.Username == req.GetUsername() {
-		msg := fmt.Sprintf("cannot follow yourself: %w", err.Error())
-		return nil, status.Error(codes.InvalidArgument, msg)
+		return nil, status.Error(codes.InvalidArgument, "cannot follow yourself")
 	}
-	otherUser, err := h.repo.GetByUsername(ctx, req.GetUsername())
+	otherUser, err := h.visibleUser(ctx, req.GetUsername())
 	if err != nil {
-		msg := fmt.Sprintf("user not found: %w", err.Error())
-		return nil, status.Error(codes.NotFound, msg)
+		return nil, err
+	}
+	if err := h.checkBlocked(ctx, u, otherUser); err != nil {
+		return nil, err
+	}
+	statuses, err := h.followStatuses(ctx, u, []*model.User{otherUser})
+	if err != nil {
+		return nil, err
+	}
+	if s := statuses[otherUser.ID]; s != pb.FollowStatus_FOLLOW_STATUS_NONE {
+		return otherUser.ProtoProfile(s), nil
+	}
+	h.suggestions.Invalidate(ctx, u.ID)
+	// following a private account waits for its approval
+	if otherUser.Private {
+		if err := h.requests.CreateFollowRequest(ctx, u.ID, otherUser.ID); err != nil {
+			msg := fmt.Sprintf("failed to request follow: %v", err)
+			return nil, status.Error(codes.Internal, msg)
+		}
+		return otherUser.ProtoProfile(pb.FollowStatus_FOLLOW_STATUS_REQUESTED), nil
 	}
 	if err := h.repo.Follow(ctx, u, otherUser); err != nil {
 		msg := fmt.Sprintf("failed to follow user: %w", err.Error())
 		return nil, status.Error(codes.NotFound, msg)
 	}
-	return u.ProtoProfile(true), nil
+	// reload for the updated follower count
+	if otherUser, err = h.visibleUser(ctx, otherUser.Username); err != nil {
+		return nil, err
+	}
+	return otherUser.ProtoProfile(pb.FollowStatus_FOLLOW_STATUS_FOLLOWING), nil
 }
 
 func (h *UserHandler) UnFollowUser(ctx context.Context, req *pb.FollowRequest) (*pb.ProfileResponse, error) {
@@ -173,37 +519,125 @@ func (h *UserHandler) UnFollowUser(ctx context.Context, req *pb.FollowRequest) (
 		return nil, err
 	}
 	if u.Username == req.GetUsername() {
-		msg := fmt.Sprintf("cannot unfollow yourself: %w", err.Error())
-		return nil, status.Error(codes.InvalidArgument, msg)
+		return nil, status.Error(codes.InvalidArgument, "cannot unfollow yourself")
 	}
 	otherUser, err := h.repo.GetByUsername(ctx, req.GetUsername())
 	if err != nil {
 		msg := fmt.Sprintf("user not found: %w", err.Error())
 		return nil, status.Error(codes.NotFound, msg)
 	}
-	following, err := h.repo.IsFollowing(ctx, u, otherUser)
+	statuses, err := h.followStatuses(ctx, u, []*model.User{otherUser})
 	if err != nil {
-		msg := fmt.Sprintf("failed to get following: %w", err.Error())
+		return nil, err
+	}
+	switch statuses[otherUser.ID] {
+	case pb.FollowStatus_FOLLOW_STATUS_FOLLOWING:
+		if err := h.repo.Unfollow(ctx, u, otherUser); err != nil {
+			msg := fmt.Sprintf("failed to unfollow user: %w", err.Error())
+			return nil, status.Error(codes.Aborted, msg)
+		}
+	case pb.FollowStatus_FOLLOW_STATUS_REQUESTED:
+		// unfollowing cancels a pending request
+		err := h.requests.DeleteFollowRequest(ctx, u.ID, otherUser.ID)
+		if err != nil && !gorm.IsRecordNotFoundError(err) {
+			msg := fmt.Sprintf("failed to cancel follow request: %v", err)
+			return nil, status.Error(codes.Aborted, msg)
+		}
+	default:
+		return nil, status.Error(codes.NotFound, "user is not following the other user")
+	}
+	h.suggestions.Invalidate(ctx, u.ID)
+	// reload for the updated follower count
+	if otherUser, err = h.repo.GetByUsername(ctx, otherUser.Username); err != nil {
+		msg := fmt.Sprintf("user not found: %v", err)
 		return nil, status.Error(codes.NotFound, msg)
 	}
-	if !following {
-		msg := fmt.Sprintf("user is not following the other user: %w", err.Error())
+	return otherUser.ProtoProfile(pb.FollowStatus_FOLLOW_STATUS_NONE), nil
+}
+
+// issueTokens creates an access token carrying the current roles of the
+// user and a refresh token in the given family. When old is set the new
+// refresh token replaces it.
+func (h *UserHandler) issueTokens(ctx context.Context, userID uint, familyID string, old *model.RefreshToken) (*pb.LoginResponse, error) {
+	roles, err := h.roles.GetUserRoles(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("failed to get roles: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	token, err := auth.GenerateToken(userID, familyID, model.RoleNames(roles), model.PermissionNames(roles))
+	if err != nil {
+		msg := fmt.Sprintf("failed to create token: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	refreshToken, hash, err := auth.GenerateRefreshToken()
+	if err != nil {
+		msg := fmt.Sprintf("failed to create refresh token: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	next := &model.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(auth.RefreshTokenDuration),
+	}
+	if old == nil {
+		err = h.tokenRepo.CreateRefreshToken(ctx, next)
+	} else {
+		err = h.tokenRepo.RotateRefreshToken(ctx, old, next)
+	}
+	if err != nil {
+		if errors.Is(err, grpc_errors.ErrRefreshTokenReused) {
+			return nil, err
+		}
+		msg := fmt.Sprintf("failed to store refresh token: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	return &pb.LoginResponse{Token: token, RefreshToken: refreshToken}, nil
+}
+
+// revokeReusedFamily revokes every token of a family after one of its
+// rotated tokens was presented again, which means it has leaked.
+func (h *UserHandler) revokeReusedFamily(ctx context.Context, t *model.RefreshToken) error {
+	h.logger.Warnf("refresh token reuse detected: user %d, family %s", t.UserID, t.FamilyID)
+	if err := h.tokenRepo.RevokeTokenFamily(ctx, t.FamilyID); err != nil {
+		h.logger.Errorf("revoke token family: %v", err)
+	}
+	err := h.sessions.RevokeSession(ctx, t.UserID, t.FamilyID)
+	if err != nil && !gorm.IsRecordNotFoundError(err) {
+		h.logger.Errorf("revoke session: %v", err)
+	}
+	return status.Error(codes.Unauthenticated, "refresh token reused")
+}
+
+// principal returns the caller authenticated by the auth interceptor
+func (h *UserHandler) principal(ctx context.Context) (*auth.Principal, error) {
+	p, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated")
+	}
+	return p, nil
+}
+
+// visibleUser finds another user by username; suspended and deactivated
+// accounts are not found
+func (h *UserHandler) visibleUser(ctx context.Context, username string) (*model.User, error) {
+	u, err := h.repo.GetByUsername(ctx, username)
+	if err != nil {
+		msg := fmt.Sprintf("user not found: %v", err)
 		return nil, status.Error(codes.NotFound, msg)
 	}
-	if err := h.repo.Unfollow(ctx, u, otherUser); err != nil {
-		msg := fmt.Sprintf("failed to unfollow user: %w", err.Error())
-		return nil, status.Error(codes.Aborted, msg)
+	if !u.IsVisible() {
+		return nil, status.Error(codes.NotFound, "user not found")
 	}
-	return u.ProtoProfile(false), nil
+	return u, nil
 }
 
 func (h *UserHandler) getUser(ctx context.Context) (*model.User, error) {
-	userID, err := auth.GetUserID(ctx)
+	principal, err := h.principal(ctx)
 	if err != nil {
-		msg := fmt.Sprintf("unauthenticated: %w", err.Error())
-		return nil, status.Error(codes.Unauthenticated, msg)
+		return nil, err
 	}
-	u, err := h.repo.GetByID(ctx, userID)
+	u, err := h.repo.GetByID(ctx, principal.UserID)
 	if err != nil {
 		msg := fmt.Sprintf("user not found: %w", err.Error())
 		return nil, status.Error(codes.NotFound, msg)
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/rezaAmiri123/service-user/gen/pb"
+	"github.com/rezaAmiri123/service-user/internal/auth"
+	"github.com/rezaAmiri123/service-user/internal/model"
+	"github.com/rezaAmiri123/service-user/pkg/mail"
+)
+
+// VerifyEmail confirms the user's email, or their pending new email, with a
+// code sent to that address
+func (h *UserHandler) VerifyEmail(ctx context.Context, req *pb.VerifyEmailRequest) (*pb.UserResponse, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "user.VerifyEmail")
+	defer span.Finish()
+
+	userID, email, err := auth.ParseVerificationCode(req.GetCode())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	u, err := h.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid verification code")
+	}
+
+	now := time.Now()
+	switch {
+	case email == u.Email:
+		if u.IsVerified() {
+			return u.ProtoUser(), nil
+		}
+	case email == u.PendingEmail:
+		if other, err := h.repo.GetByEmail(ctx, email); err == nil && other.ID != u.ID {
+			return nil, status.Error(codes.AlreadyExists, "email already exists")
+		}
+		u.Email = u.PendingEmail
+		u.PendingEmail = ""
+	default:
+		// the code was issued for an email the user no longer uses
+		return nil, status.Error(codes.InvalidArgument, "invalid verification code")
+	}
+	u.VerifiedAt = &now
+	columns := []string{"email", "pending_email", "verified_at"}
+	if u.Status == model.UserStatusPendingVerification {
+		u.SetStatus(model.UserStatusActive, "", nil)
+		columns = append(columns, model.StatusColumns...)
+	}
+
+	if err := h.repo.Update(ctx, u, columns...); err != nil {
+		msg := fmt.Sprintf("failed to update: %v", err)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	return u.ProtoUser(), nil
+}
+
+// sendVerification mails a verification link for email in the background
+func (h *UserHandler) sendVerification(u *model.User, email string) {
+	code, err := auth.GenerateVerificationCode(u.ID, email)
+	if err != nil {
+		h.logger.Errorf("create verification code: %v", err)
+		return
+	}
+	msg := &mail.Message{
+		To:      email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf(
+			"Hi %s, use the link below to verify your email. It expires in %v.\n\n%s?code=%s\n",
+			u.Username,
+			auth.EmailVerificationDuration,
+			h.cfg.Mail.VerifyURL,
+			url.QueryEscape(code),
+		),
+	}
+	go func() {
+		if err := h.mailer.Send(context.Background(), msg); err != nil {
+			h.logger.Errorf("send verification mail: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,145 @@
+package interceptors
+
+import (
+	"context"
+	"fmt"
+
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/rezaAmiri123/service-user/internal/auth"
+)
+
+// Policy is the access rule of an RPC
+type Policy int
+
+const (
+	// PolicyDeny rejects every call; it applies to methods missing from
+	// the policy table
+	PolicyDeny Policy = iota
+	// PolicyPublic allows unauthenticated calls
+	PolicyPublic
+	// PolicyAuthenticated requires a valid access token
+	PolicyAuthenticated
+	// PolicyPermission requires a valid access token granting the
+	// permission of the method in methodPermissions
+	PolicyPermission
+)
+
+// methodPolicies is the access policy of every RPC served
+var methodPolicies = map[string]Policy{
+	"/user.Users/CreateUser":             PolicyPublic,
+	"/user.Users/LoginUser":              PolicyPublic,
+	"/user.Users/RefreshToken":           PolicyPublic,
+	"/user.Users/GetJWKS":                PolicyPublic,
+	"/user.Users/RequestPasswordReset":   PolicyPublic,
+	"/user.Users/ResetPassword":          PolicyPublic,
+	"/user.Users/VerifyEmail":            PolicyPublic,
+	"/user.Users/VerifyMFA":              PolicyPublic,
+	"/user.Users/EnrollTOTP":             PolicyAuthenticated,
+	"/user.Users/ConfirmTOTP":            PolicyAuthenticated,
+	"/user.Users/Logout":                 PolicyAuthenticated,
+	"/user.Users/ListSessions":           PolicyAuthenticated,
+	"/user.Users/RevokeSession":          PolicyAuthenticated,
+	"/user.Users/RevokeAllOtherSessions": PolicyAuthenticated,
+	"/user.Users/DeleteAccount":          PolicyAuthenticated,
+	"/user.Users/ExportMyData":           PolicyAuthenticated,
+	"/user.Users/GetUser":                PolicyAuthenticated,
+	"/user.Users/UpdateUser":             PolicyAuthenticated,
+	"/user.Users/UploadAvatar":           PolicyAuthenticated,
+	"/user.Users/GetProfile":             PolicyAuthenticated,
+	"/user.Users/BatchGetProfiles":       PolicyAuthenticated,
+	"/user.Users/FollowUser":             PolicyAuthenticated,
+	"/user.Users/UnFollowUser":           PolicyAuthenticated,
+	"/user.Users/ListFollowers":          PolicyAuthenticated,
+	"/user.Users/ListFollowing":          PolicyAuthenticated,
+	"/user.Users/BlockUser":              PolicyAuthenticated,
+	"/user.Users/UnblockUser":            PolicyAuthenticated,
+	"/user.Users/ListBlocked":            PolicyAuthenticated,
+	"/user.Users/ListFollowRequests":     PolicyAuthenticated,
+	"/user.Users/ApproveFollowRequest":   PolicyAuthenticated,
+	"/user.Users/RejectFollowRequest":    PolicyAuthenticated,
+	"/user.Users/SuggestFollows":         PolicyAuthenticated,
+	"/user.Users/UnlockAccount":          PolicyPermission,
+	"/user.Users/ImportUsers":            PolicyPermission,
+	"/user.Users/GrantRole":              PolicyPermission,
+	"/user.Users/RevokeRole":             PolicyPermission,
+	"/user.UserAdmin/ListUsers":          PolicyPermission,
+	"/user.UserAdmin/GetUser":            PolicyPermission,
+	"/user.UserAdmin/UpdateUser":         PolicyPermission,
+	"/user.UserAdmin/ForcePasswordReset": PolicyPermission,
+	"/user.UserAdmin/SuspendUser":        PolicyPermission,
+	"/user.UserAdmin/UnsuspendUser":      PolicyPermission,
+	"/user.UserAdmin/SetUserStatus":      PolicyPermission,
+	"/user.UserAdmin/DeleteUser":         PolicyPermission,
+	"/user.UserAdmin/ExportUserData":     PolicyPermission,
+	"/user.UserAdmin/ListAuditEvents":    PolicyPermission,
+}
+
+// methodPermissions is the permission required by every RPC with
+// PolicyPermission
+var methodPermissions = map[string]string{
+	"/user.Users/UnlockAccount":          auth.PermissionUnlockUsers,
+	"/user.Users/ImportUsers":            auth.PermissionImportUsers,
+	"/user.Users/GrantRole":              auth.PermissionManageRoles,
+	"/user.Users/RevokeRole":             auth.PermissionManageRoles,
+	"/user.UserAdmin/ListUsers":          auth.PermissionReadUsers,
+	"/user.UserAdmin/GetUser":            auth.PermissionReadUsers,
+	"/user.UserAdmin/UpdateUser":         auth.PermissionWriteUsers,
+	"/user.UserAdmin/ForcePasswordReset": auth.PermissionWriteUsers,
+	"/user.UserAdmin/SuspendUser":        auth.PermissionSuspendUsers,
+	"/user.UserAdmin/UnsuspendUser":      auth.PermissionSuspendUsers,
+	"/user.UserAdmin/SetUserStatus":      auth.PermissionSuspendUsers,
+	"/user.UserAdmin/DeleteUser":         auth.PermissionDeleteUsers,
+	"/user.UserAdmin/ExportUserData":     auth.PermissionExportUsers,
+	"/user.UserAdmin/ListAuditEvents":    auth.PermissionReadAudit,
+}
+
+// Auth authenticates unary calls and enforces the method policy
+func (im *InterceptorManager) Auth(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := im.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// StreamAuth authenticates streaming calls and enforces the method policy
+func (im *InterceptorManager) StreamAuth(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := im.authorize(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	wrapped := grpc_middleware.WrapServerStream(ss)
+	wrapped.WrappedContext = ctx
+	return handler(srv, wrapped)
+}
+
+// authorize applies the policy of the method and places the authenticated
+// principal in the returned context
+func (im *InterceptorManager) authorize(ctx context.Context, method string) (context.Context, error) {
+	policy := methodPolicies[method]
+	switch policy {
+	case PolicyDeny:
+		return nil, status.Error(codes.PermissionDenied, "method is not allowed")
+	case PolicyPublic:
+		return ctx, nil
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		msg := fmt.Sprintf("unauthenticated: %v", err)
+		return nil, status.Error(codes.Unauthenticated, msg)
+	}
+	principal := auth.NewPrincipal(claims)
+	if policy == PolicyPermission {
+		permission, ok := methodPermissions[method]
+		if !ok || !principal.HasPermission(permission) {
+			msg := fmt.Sprintf("permission %q required", permission)
+			return nil, status.Error(codes.PermissionDenied, msg)
+		}
+	}
+	return auth.ContextWithPrincipal(ctx, principal), nil
+}
@@ -0,0 +1,119 @@
+package interceptors
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/rezaAmiri123/service-user/gen/pb"
+	"github.com/rezaAmiri123/service-user/internal/auth"
+)
+
+// servedMethods returns the full names of every RPC of the served services
+func servedMethods() []string {
+	var methods []string
+	for _, desc := range []grpc.ServiceDesc{pb.Users_ServiceDesc, pb.UserAdmin_ServiceDesc} {
+		for _, m := range desc.Methods {
+			methods = append(methods, "/"+desc.ServiceName+"/"+m.MethodName)
+		}
+		for _, s := range desc.Streams {
+			methods = append(methods, "/"+desc.ServiceName+"/"+s.StreamName)
+		}
+	}
+	return methods
+}
+
+func TestEveryMethodHasPolicy(t *testing.T) {
+	served := make(map[string]bool)
+	for _, method := range servedMethods() {
+		served[method] = true
+		policy, ok := methodPolicies[method]
+		if !ok {
+			t.Errorf("%s has no policy and is denied", method)
+			continue
+		}
+		if _, ok := methodPermissions[method]; ok != (policy == PolicyPermission) {
+			t.Errorf("%s: policy %d, permission listed %v", method, policy, ok)
+		}
+	}
+	for method := range methodPolicies {
+		if !served[method] {
+			t.Errorf("policy of %s, which is not served", method)
+		}
+	}
+}
+
+// withToken returns an incoming context carrying an access token with the
+// given permissions
+func withToken(t *testing.T, permissions ...string) context.Context {
+	t.Helper()
+	token, err := auth.GenerateToken(1, "", nil, permissions)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Token "+token))
+}
+
+func TestAuthorize(t *testing.T) {
+	auth.SetJWTSecret("test secret")
+	defer auth.SetJWTSecret("")
+
+	none := func(t *testing.T) context.Context { return context.Background() }
+	user := func(t *testing.T) context.Context { return withToken(t) }
+	reader := func(t *testing.T) context.Context { return withToken(t, auth.PermissionReadUsers) }
+	tests := []struct {
+		name   string
+		method string
+		ctx    func(t *testing.T) context.Context
+		want   codes.Code
+	}{
+		{"signup without token", "/user.Users/CreateUser", none, codes.OK},
+		{"login without token", "/user.Users/LoginUser", none, codes.OK},
+		{"refresh without token", "/user.Users/RefreshToken", none, codes.OK},
+		{"jwks without token", "/user.Users/GetJWKS", none, codes.OK},
+		{"mfa without token", "/user.Users/VerifyMFA", none, codes.OK},
+		{"password reset without token", "/user.Users/ResetPassword", none, codes.OK},
+		{"authenticated without token", "/user.Users/GetUser", none, codes.Unauthenticated},
+		{"authenticated with token", "/user.Users/GetUser", user, codes.OK},
+		{"upload stream without token", "/user.Users/UploadAvatar", none, codes.Unauthenticated},
+		{"invalid token", "/user.Users/GetUser", func(t *testing.T) context.Context {
+			return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Token invalid"))
+		}, codes.Unauthenticated},
+		{"admin without token", "/user.UserAdmin/ListUsers", none, codes.Unauthenticated},
+		{"admin without permission", "/user.UserAdmin/ListUsers", user, codes.PermissionDenied},
+		{"admin with permission", "/user.UserAdmin/ListUsers", reader, codes.OK},
+		{"admin with another permission", "/user.UserAdmin/DeleteUser", reader, codes.PermissionDenied},
+		{"role grant without permission", "/user.Users/GrantRole", user, codes.PermissionDenied},
+		{"unknown method", "/user.Users/Unknown", user, codes.PermissionDenied},
+	}
+	im := &InterceptorManager{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := im.authorize(tt.ctx(t), tt.method)
+			if got := status.Code(err); got != tt.want {
+				t.Errorf("authorize(%s) = %v, want %v", tt.method, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorizePermissionMethods(t *testing.T) {
+	auth.SetJWTSecret("test secret")
+	defer auth.SetJWTSecret("")
+
+	im := &InterceptorManager{}
+	for method, permission := range methodPermissions {
+		t.Run(method, func(t *testing.T) {
+			if _, err := im.authorize(withToken(t), method); status.Code(err) != codes.PermissionDenied {
+				t.Errorf("without %s: %v, want PermissionDenied", permission, err)
+			}
+			if _, err := im.authorize(withToken(t, permission), method); err != nil {
+				t.Errorf("with %s: %v", permission, err)
+			}
+		})
+	}
+}
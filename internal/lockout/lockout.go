@@ -0,0 +1,119 @@
+package lockout
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/rezaAmiri123/service-user/cmd/config"
+	"github.com/rezaAmiri123/service-user/internal/repository"
+	"github.com/rezaAmiri123/service-user/pkg/logger"
+)
+
+// Guard throttles failed logins per account and per client IP: every
+// failure delays the next attempt a bit more, and too many failures lock
+// the account or IP for a while
+type Guard struct {
+	repo   repository.AttemptRepository
+	cfg    config.LockoutConfig
+	logger logger.Logger
+}
+
+func NewGuard(repo repository.AttemptRepository, cfg config.LockoutConfig, logger logger.Logger) *Guard {
+	return &Guard{repo: repo, cfg: cfg, logger: logger}
+}
+
+// Check returns how long the account or IP stays locked, zero when neither
+// is locked
+func (g *Guard) Check(ctx context.Context, account, ip string) (time.Duration, error) {
+	var retry time.Duration
+	for _, key := range g.keys(account, ip) {
+		ttl, err := g.repo.LockTTL(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+		if ttl > retry {
+			retry = ttl
+		}
+	}
+	return retry, nil
+}
+
+// Delay waits longer the more failures the account has seen recently
+func (g *Guard) Delay(ctx context.Context, account string) error {
+	n, err := g.repo.Count(ctx, accountKey(account))
+	if err != nil || n == 0 {
+		return err
+	}
+	d := g.cfg.BaseDelay * time.Millisecond
+	max := g.cfg.MaxDelay * time.Second
+	for i := int64(1); i < n && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Fail counts a failed attempt. It returns the lock duration when this
+// failure locked the account or IP.
+func (g *Guard) Fail(ctx context.Context, account, ip string) (time.Duration, error) {
+	var locked time.Duration
+	lockDuration := g.cfg.LockDuration * time.Minute
+	limits := map[string]int{accountKey(account): g.cfg.MaxAttempts}
+	if ip != "" {
+		limits[ipKey(ip)] = g.cfg.IPMaxAttempts
+	}
+	for key, max := range limits {
+		n, err := g.repo.Incr(ctx, key, g.cfg.Window*time.Minute)
+		if err != nil {
+			return 0, err
+		}
+		if max > 0 && n >= int64(max) {
+			g.logger.Warnf("login locked: %s after %d failures", key, n)
+			if err := g.repo.Lock(ctx, key, lockDuration); err != nil {
+				return 0, err
+			}
+			if err := g.repo.Reset(ctx, key); err != nil {
+				return 0, err
+			}
+			locked = lockDuration
+		}
+	}
+	return locked, nil
+}
+
+// Succeed clears the failures of an account. IP failures are kept so one
+// valid account cannot be used to reset them.
+func (g *Guard) Succeed(ctx context.Context, account string) error {
+	return g.repo.Reset(ctx, accountKey(account))
+}
+
+// Unlock removes the lock and the failures of an account
+func (g *Guard) Unlock(ctx context.Context, account string) error {
+	return g.repo.Unlock(ctx, accountKey(account))
+}
+
+func (g *Guard) keys(account, ip string) []string {
+	keys := []string{accountKey(account)}
+	if ip != "" {
+		keys = append(keys, ipKey(ip))
+	}
+	return keys
+}
+
+func accountKey(account string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(account))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
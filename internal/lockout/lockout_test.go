@@ -0,0 +1,194 @@
+package lockout
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rezaAmiri123/service-user/cmd/config"
+	"github.com/rezaAmiri123/service-user/internal/repository"
+	"github.com/rezaAmiri123/service-user/pkg/logger"
+)
+
+func newTestGuard() *Guard {
+	appLogger := logger.NewAPILogger(&config.Config{Logger: config.LoggerConfig{Level: "fatal", Encoding: "console"}})
+	appLogger.InitLogger()
+	cfg := config.LockoutConfig{
+		MaxAttempts:   3,
+		IPMaxAttempts: 5,
+		Window:        15,
+		LockDuration:  10,
+		BaseDelay:     1,
+		MaxDelay:      1,
+	}
+	return NewGuard(repository.NewInMemoryAttemptRepo(), cfg, appLogger)
+}
+
+// attempt is a failed login of an account from an IP
+type attempt struct {
+	account, ip string
+}
+
+func TestGuardLocks(t *testing.T) {
+	lockDuration := 10 * time.Minute
+	tests := []struct {
+		name     string
+		failures []attempt
+		// succeed clears the failures of this account after the failures
+		succeed    string
+		check      attempt
+		wantLocked bool
+	}{
+		{
+			name:     "below the account limit",
+			failures: []attempt{{"a@x.io", "1.1.1.1"}, {"a@x.io", "1.1.1.1"}},
+			check:    attempt{"a@x.io", "1.1.1.1"},
+		},
+		{
+			name:       "account limit reached",
+			failures:   []attempt{{"a@x.io", "1.1.1.1"}, {"a@x.io", "2.2.2.2"}, {"a@x.io", "3.3.3.3"}},
+			check:      attempt{"a@x.io", "4.4.4.4"},
+			wantLocked: true,
+		},
+		{
+			name:       "account is case and space insensitive",
+			failures:   []attempt{{"A@x.io", ""}, {" a@X.io", ""}, {"a@x.io ", ""}},
+			check:      attempt{"a@x.io", ""},
+			wantLocked: true,
+		},
+		{
+			name:     "other accounts are not locked",
+			failures: []attempt{{"a@x.io", ""}, {"a@x.io", ""}, {"a@x.io", ""}},
+			check:    attempt{"b@x.io", ""},
+		},
+		{
+			name: "ip limit reached across accounts",
+			failures: []attempt{
+				{"a@x.io", "1.1.1.1"}, {"b@x.io", "1.1.1.1"}, {"c@x.io", "1.1.1.1"},
+				{"d@x.io", "1.1.1.1"}, {"e@x.io", "1.1.1.1"},
+			},
+			check:      attempt{"f@x.io", "1.1.1.1"},
+			wantLocked: true,
+		},
+		{
+			name:     "success clears account failures",
+			failures: []attempt{{"a@x.io", ""}, {"a@x.io", ""}},
+			succeed:  "a@x.io",
+			check:    attempt{"a@x.io", ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			g := newTestGuard()
+			var locked time.Duration
+			for _, f := range tt.failures {
+				d, err := g.Fail(ctx, f.account, f.ip)
+				if err != nil {
+					t.Fatalf("Fail: %v", err)
+				}
+				if d > 0 {
+					locked = d
+				}
+			}
+			if tt.succeed != "" {
+				if err := g.Succeed(ctx, tt.succeed); err != nil {
+					t.Fatalf("Succeed: %v", err)
+				}
+			}
+			retry, err := g.Check(ctx, tt.check.account, tt.check.ip)
+			if err != nil {
+				t.Fatalf("Check: %v", err)
+			}
+			if got := retry > 0; got != tt.wantLocked {
+				t.Fatalf("Check = %v, want locked %v", retry, tt.wantLocked)
+			}
+			if tt.wantLocked && (locked != lockDuration || retry > lockDuration) {
+				t.Errorf("locked for %v, retry after %v, want %v", locked, retry, lockDuration)
+			}
+		})
+	}
+}
+
+func TestGuardSucceedKeepsIPFailures(t *testing.T) {
+	ctx := context.Background()
+	g := newTestGuard()
+	for i := 0; i < 4; i++ {
+		if _, err := g.Fail(ctx, "a@x.io", "1.1.1.1"); err != nil {
+			t.Fatalf("Fail: %v", err)
+		}
+		if err := g.Succeed(ctx, "a@x.io"); err != nil {
+			t.Fatalf("Succeed: %v", err)
+		}
+	}
+	locked, err := g.Fail(ctx, "a@x.io", "1.1.1.1")
+	if err != nil {
+		t.Fatalf("Fail: %v", err)
+	}
+	if locked == 0 {
+		t.Fatal("the fifth failure from an IP did not lock it")
+	}
+}
+
+func TestGuardUnlock(t *testing.T) {
+	ctx := context.Background()
+	g := newTestGuard()
+	for i := 0; i < 3; i++ {
+		if _, err := g.Fail(ctx, "a@x.io", ""); err != nil {
+			t.Fatalf("Fail: %v", err)
+		}
+	}
+	if retry, _ := g.Check(ctx, "a@x.io", ""); retry == 0 {
+		t.Fatal("account is not locked")
+	}
+	if err := g.Unlock(ctx, "A@x.io"); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	if retry, _ := g.Check(ctx, "a@x.io", ""); retry != 0 {
+		t.Fatalf("account still locked for %v after Unlock", retry)
+	}
+}
+
+func TestGuardDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		minDelay time.Duration
+	}{
+		{"no failures", 0, 0},
+		{"one failure", 1, time.Millisecond},
+		{"delay doubles", 2, 2 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			g := newTestGuard()
+			for i := 0; i < tt.failures; i++ {
+				if _, err := g.Fail(ctx, "a@x.io", ""); err != nil {
+					t.Fatalf("Fail: %v", err)
+				}
+			}
+			start := time.Now()
+			if err := g.Delay(ctx, "a@x.io"); err != nil {
+				t.Fatalf("Delay: %v", err)
+			}
+			if elapsed := time.Since(start); elapsed < tt.minDelay {
+				t.Errorf("Delay waited %v, want at least %v", elapsed, tt.minDelay)
+			}
+		})
+	}
+}
+
+func TestGuardDelayCanceled(t *testing.T) {
+	g := newTestGuard()
+	g.cfg.BaseDelay = 60 * 1000
+	g.cfg.MaxDelay = 60
+	if _, err := g.Fail(context.Background(), "a@x.io", ""); err != nil {
+		t.Fatalf("Fail: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := g.Delay(ctx, "a@x.io"); err != context.Canceled {
+		t.Fatalf("Delay = %v, want %v", err, context.Canceled)
+	}
+}
@@ -2,24 +2,86 @@ package model
 
 import (
 	"errors"
-	"github.com/rezaAmiri123/service-user/pkg/utils"
 	"regexp"
+	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation"
 	"github.com/go-ozzo/ozzo-validation/is"
 	"github.com/jinzhu/gorm"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
 	pb "github.com/rezaAmiri123/service-user/gen/pb"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/rezaAmiri123/service-user/internal/password"
+	"github.com/rezaAmiri123/service-user/pkg/utils"
+)
+
+// Account statuses
+const (
+	UserStatusActive = "active"
+	// UserStatusSuspended blocks the account, until StatusExpiresAt when
+	// it is set
+	UserStatusSuspended = "suspended"
+	// UserStatusPendingVerification blocks login until the email is
+	// verified
+	UserStatusPendingVerification = "pending_verification"
+	UserStatusDeactivated         = "deactivated"
+	// UserStatusPendingDeletion marks an account deleted by its user or by
+	// staff; it is purged at StatusExpiresAt unless the user logs in
+	// before
+	UserStatusPendingDeletion = "pending_deletion"
+	// UserStatusDeleted marks a purged account that was anonymized
+	UserStatusDeleted = "deleted"
 )
 
+// StatusColumns are the columns SetStatus changes
+var StatusColumns = []string{"status", "status_reason", "status_expires_at"}
+
+// IsUserStatus reports whether s is a status staff can set directly
+func IsUserStatus(s string) bool {
+	switch s {
+	case UserStatusActive, UserStatusSuspended, UserStatusPendingVerification, UserStatusDeactivated:
+		return true
+	}
+	return false
+}
+
 // User is user model
 type User struct {
 	gorm.Model
 	Username string `json:"username"`
 	Password string `json:"password"`
 	Email    string `json:"email"`
-	Bio string `json:"bio"`
-	Image string `json:"image"`
+	Bio      string `json:"bio"`
+	Image    string `json:"image"`
+	// VerifiedAt is when Email was verified, nil while it is not
+	VerifiedAt *time.Time `json:"verified_at"`
+	// PendingEmail is a new email waiting for verification; Email stays
+	// in use until it is verified
+	PendingEmail string `json:"pending_email"`
+	// TOTPSecret is the encrypted RFC 6238 secret, TOTPEnabled is set once
+	// the user confirmed enrollment with a valid code. The secret is never
+	// cached, load it with UserRepository.GetTOTPSecret.
+	TOTPSecret  string `json:"-"`
+	TOTPEnabled bool   `json:"totp_enabled"`
+	// TOTPLastStep is the time step of the last accepted code, so a code
+	// cannot be used twice
+	TOTPLastStep int64 `json:"totp_last_step"`
+	// Status is one of the UserStatus constants; StatusReason and
+	// StatusExpiresAt describe why and until when it applies. For a
+	// pending deletion StatusExpiresAt is when the account is purged.
+	Status          string     `json:"status" gorm:"default:'active';index"`
+	StatusReason    string     `json:"status_reason"`
+	StatusExpiresAt *time.Time `json:"status_expires_at"`
+	// PasswordResetRequired blocks login until the password is reset
+	PasswordResetRequired bool `json:"password_reset_required"`
+	// Private makes follows requests the user has to approve
+	Private bool `json:"private"`
+	// FollowersCount and FollowingCount count the rows of follows of the
+	// user; only the follow queries of the user repository change them
+	FollowersCount int64 `json:"followers_count" gorm:"not null;default:0"`
+	FollowingCount int64 `json:"following_count" gorm:"not null;default:0"`
+	// Roles are loaded through the role repository only
+	Roles   []Role `json:"-" gorm:"many2many:user_roles"`
 	Follows []User `json:"follows" gorm:"many2many:follows;jointable_foreignkey:from_user_id;association_jointable_foreignkey:to_user_id"`
 	//Follows []*User `json:"follows" gorm:"many2many:follows"` // follows_id and user_id
 }
@@ -33,44 +95,156 @@ func (u User) Validate() error {
 			validation.Match(regexp.MustCompile("[a-zA-Z0-9]+")),
 		),
 		validation.Field(&u.Email, validation.Required, is.Email),
+		validation.Field(&u.PendingEmail, is.Email),
 		validation.Field(&u.Password, validation.Required),
 	)
 }
 
+// IsVerified reports whether the user verified their email
+func (u *User) IsVerified() bool {
+	return u.VerifiedAt != nil
+}
+
+// CurrentStatus returns the status of the account at t; a suspension past
+// its expiry is active again
+func (u *User) CurrentStatus(t time.Time) string {
+	if u.Status == "" {
+		return UserStatusActive
+	}
+	if u.Status == UserStatusSuspended && u.StatusExpiresAt != nil && !t.Before(*u.StatusExpiresAt) {
+		return UserStatusActive
+	}
+	return u.Status
+}
+
+// IsActive reports whether the account can be used now
+func (u *User) IsActive() bool {
+	return u.CurrentStatus(time.Now()) == UserStatusActive
+}
+
+// IsVisible reports whether other users can see the account
+func (u *User) IsVisible() bool {
+	switch u.CurrentStatus(time.Now()) {
+	case UserStatusSuspended, UserStatusDeactivated, UserStatusPendingDeletion, UserStatusDeleted:
+		return false
+	}
+	return true
+}
+
+// SetStatus changes the status of the account; expiresAt only applies to
+// suspensions and pending deletions
+func (u *User) SetStatus(status, reason string, expiresAt *time.Time) {
+	u.Status = status
+	u.StatusReason = reason
+	u.StatusExpiresAt = nil
+	if status == UserStatusSuspended || status == UserStatusPendingDeletion {
+		u.StatusExpiresAt = expiresAt
+	}
+}
+
+// Anonymize replaces the personal data of a purged account
+func (u *User) Anonymize() {
+	id := utils.UintToString(u.ID)
+	u.Username = "deleted-" + id
+	u.Email = "deleted-" + id + "@invalid"
+	u.Password = ""
+	u.Bio = ""
+	u.Image = ""
+	u.VerifiedAt = nil
+	u.PendingEmail = ""
+	u.TOTPSecret = ""
+	u.TOTPEnabled = false
+	u.TOTPLastStep = 0
+	u.PasswordResetRequired = false
+	u.SetStatus(UserStatusDeleted, "", nil)
+}
+
 // HashPassword makes password field crypted
 func (u *User) HashPassword() error {
 	if len(u.Password) == 0 {
 		return errors.New("password should not be empty")
 	}
-	h, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
+	h, err := password.Hash(u.Password)
 	if err != nil {
 		return err
 	}
-	u.Password = string(h)
+	u.Password = h
 	return nil
 }
 
 // CheckPassword checks user password correct
 func (u *User) CheckPassword(plain string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(plain))
-	return err == nil
+	ok, _ := password.Verify(u.Password, plain)
+	return ok
+}
+
+// UpgradePassword checks the password and, when it is correct but hashed
+// with an outdated algorithm or cost, rehashes it. It reports whether the
+// password was correct and whether the hash changed.
+func (u *User) UpgradePassword(plain string) (ok bool, changed bool) {
+	ok, rehash := password.Verify(u.Password, plain)
+	if !ok || !rehash {
+		return ok, false
+	}
+	h, err := password.Hash(plain)
+	if err != nil {
+		return true, false
+	}
+	u.Password = h
+	return true, true
 }
 
 // ProtoUser return user proto
 func (u *User) ProtoUser() *pb.UserResponse {
 	return &pb.UserResponse{
-		Id:       utils.UintToString(u.ID),
-		Email:    u.Email,
-		Username: u.Username,
+		Id:            utils.UintToString(u.ID),
+		Email:         u.Email,
+		Username:      u.Username,
+		EmailVerified: u.IsVerified(),
+		PendingEmail:  u.PendingEmail,
+		Status:        u.CurrentStatus(time.Now()),
+		Bio:           u.Bio,
+		Image:         u.Image,
+		Private:       u.Private,
+	}
+}
+
+// ProtoAdminUser return the proto user of the admin API
+func (u *User) ProtoAdminUser() *pb.AdminUser {
+	user := &pb.AdminUser{
+		Id:                    utils.UintToString(u.ID),
+		Username:              u.Username,
+		Email:                 u.Email,
+		EmailVerified:         u.IsVerified(),
+		PendingEmail:          u.PendingEmail,
+		Bio:                   u.Bio,
+		Image:                 u.Image,
+		Status:                u.CurrentStatus(time.Now()),
+		StatusReason:          u.StatusReason,
+		MfaEnabled:            u.TOTPEnabled,
+		PasswordResetRequired: u.PasswordResetRequired,
+		Private:               u.Private,
+		CreatedAt:             timestamppb.New(u.CreatedAt),
+		UpdatedAt:             timestamppb.New(u.UpdatedAt),
+	}
+	if u.StatusExpiresAt != nil && (user.Status == UserStatusSuspended || user.Status == UserStatusPendingDeletion) {
+		user.StatusExpiresAt = timestamppb.New(*u.StatusExpiresAt)
 	}
+	return user
 }
 
-// ProtoProfile return proto profile
-func (u *User) ProtoProfile(following bool) *pb.ProfileResponse {
+// ProtoProfile return proto profile; followStatus is whether the caller
+// follows the user
+func (u *User) ProtoProfile(followStatus pb.FollowStatus) *pb.ProfileResponse {
 	return &pb.ProfileResponse{
-		Username: u.Username,
-		Bio: u.Bio,
-		Image: u.Image,
-		Following: following,
+		Id:             utils.UintToString(u.ID),
+		Username:       u.Username,
+		Bio:            u.Bio,
+		Image:          u.Image,
+		Following:      followStatus == pb.FollowStatus_FOLLOW_STATUS_FOLLOWING,
+		FollowersCount: u.FollowersCount,
+		FollowingCount: u.FollowingCount,
+		FollowStatus:   followStatus,
+		Private:        u.Private,
 	}
 }
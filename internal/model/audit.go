@@ -0,0 +1,31 @@
+package model
+
+import (
+	"github.com/jinzhu/gorm"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/rezaAmiri123/service-user/gen/pb"
+	"github.com/rezaAmiri123/service-user/pkg/utils"
+)
+
+// AuditEvent records an action staff took on a user account
+type AuditEvent struct {
+	gorm.Model
+	ActorID      uint   `gorm:"index"`
+	Action       string `gorm:"index"`
+	TargetUserID uint   `gorm:"index"`
+	// Details is a JSON object describing the change
+	Details string `gorm:"type:text"`
+}
+
+// ProtoAuditEvent return proto audit event
+func (e *AuditEvent) ProtoAuditEvent() *pb.AuditEvent {
+	return &pb.AuditEvent{
+		Id:           utils.UintToString(e.ID),
+		ActorId:      utils.UintToString(e.ActorID),
+		Action:       e.Action,
+		TargetUserId: utils.UintToString(e.TargetUserID),
+		Details:      e.Details,
+		CreatedAt:    timestamppb.New(e.CreatedAt),
+	}
+}
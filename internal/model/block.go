@@ -0,0 +1,14 @@
+package model
+
+import (
+	"time"
+)
+
+// Block stops two users from following each other and the blocked user
+// from viewing the profile of the blocker
+type Block struct {
+	ID        uint `gorm:"primary_key"`
+	BlockerID uint `gorm:"unique_index:idx_blocks_pair"`
+	BlockedID uint `gorm:"unique_index:idx_blocks_pair;index"`
+	CreatedAt time.Time
+}
@@ -0,0 +1,13 @@
+package model
+
+import (
+	"time"
+)
+
+// FollowRequest is a follow of a private account waiting for its approval
+type FollowRequest struct {
+	ID         uint `gorm:"primary_key"`
+	FromUserID uint `gorm:"unique_index:idx_follow_requests_pair"`
+	ToUserID   uint `gorm:"unique_index:idx_follow_requests_pair;index"`
+	CreatedAt  time.Time
+}
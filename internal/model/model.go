@@ -7,5 +7,14 @@ import (
 func AutoMigrate(db *gorm.DB) error {
 	return db.AutoMigrate(
 		&User{},
+		&RefreshToken{},
+		&PasswordResetToken{},
+		&RecoveryCode{},
+		&Session{},
+		&Role{},
+		&Permission{},
+		&AuditEvent{},
+		&Block{},
+		&FollowRequest{},
 	).Error
 }
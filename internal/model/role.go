@@ -0,0 +1,42 @@
+package model
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// Role is a named set of permissions granted to users
+type Role struct {
+	gorm.Model
+	Name        string       `gorm:"unique_index"`
+	Permissions []Permission `gorm:"many2many:role_permissions"`
+}
+
+// Permission allows an action, e.g. "users:unlock"
+type Permission struct {
+	gorm.Model
+	Name string `gorm:"unique_index"`
+}
+
+// PermissionNames returns the distinct permissions granted by roles
+func PermissionNames(roles []*Role) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, r := range roles {
+		for _, p := range r.Permissions {
+			if !seen[p.Name] {
+				seen[p.Name] = true
+				names = append(names, p.Name)
+			}
+		}
+	}
+	return names
+}
+
+// RoleNames returns the names of roles
+func RoleNames(roles []*Role) []string {
+	names := make([]string, 0, len(roles))
+	for _, r := range roles {
+		names = append(names, r.Name)
+	}
+	return names
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/rezaAmiri123/service-user/gen/pb"
+)
+
+// Session is a login of a user on one device. Its SessionID is the family id
+// of the refresh tokens issued for the login and the sid claim of its access
+// tokens.
+type Session struct {
+	gorm.Model
+	UserID     uint   `gorm:"index"`
+	SessionID  string `gorm:"unique_index"`
+	UserAgent  string
+	IP         string
+	LastSeenAt time.Time
+	// ExpiresAt follows the expiry of the newest refresh token of the
+	// session; expired sessions are no longer active
+	ExpiresAt time.Time `gorm:"index"`
+	RevokedAt *time.Time
+}
+
+// ProtoSession return proto session; current marks the session of the caller
+func (s *Session) ProtoSession(current bool) *pb.Session {
+	return &pb.Session{
+		Id:         s.SessionID,
+		UserAgent:  s.UserAgent,
+		Ip:         s.IP,
+		CreatedAt:  timestamppb.New(s.CreatedAt),
+		LastSeenAt: timestamppb.New(s.LastSeenAt),
+		Current:    current,
+	}
+}
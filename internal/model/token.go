@@ -0,0 +1,49 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// RefreshToken is an opaque, server-side refresh token. Only a hash of the
+// token is stored. Tokens issued from the same login share a FamilyID, so
+// reuse of an already rotated token can revoke the whole chain.
+type RefreshToken struct {
+	gorm.Model
+	UserID    uint   `gorm:"index"`
+	FamilyID  string `gorm:"index"`
+	TokenHash string `gorm:"unique_index"`
+	ExpiresAt time.Time
+	RotatedAt *time.Time
+	RevokedAt *time.Time
+}
+
+// IsExpired reports whether the token can no longer be used
+func (t *RefreshToken) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
+
+// PasswordResetToken is a single-use token that lets a user set a new
+// password. Only a hash of the token is stored.
+type PasswordResetToken struct {
+	gorm.Model
+	UserID    uint   `gorm:"index"`
+	TokenHash string `gorm:"unique_index"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+// IsExpired reports whether the token can no longer be used
+func (t *PasswordResetToken) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
+
+// RecoveryCode is a one-time code that replaces a TOTP code when the user
+// lost their authenticator. Only a hash of the code is stored.
+type RecoveryCode struct {
+	gorm.Model
+	UserID   uint   `gorm:"index"`
+	CodeHash string `gorm:"index"`
+	UsedAt   *time.Time
+}
@@ -0,0 +1,274 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/rezaAmiri123/service-user/cmd/config"
+)
+
+// Scheme is a password hashing algorithm with its parameters
+type Scheme interface {
+	// Recognizes reports whether an encoded hash was made by this algorithm
+	Recognizes(encoded string) bool
+	Hash(plain string) (string, error)
+	Verify(encoded, plain string) (bool, error)
+	// NeedsRehash reports whether an encoded hash of this algorithm uses
+	// weaker parameters than the scheme
+	NeedsRehash(encoded string) bool
+}
+
+// Hasher hashes new passwords with its current scheme and verifies hashes
+// of every scheme it knows
+type Hasher struct {
+	current Scheme
+	schemes []Scheme
+}
+
+// NewHasher returns a hasher whose current scheme is cfg.Algorithm; hashes
+// of the other built-in schemes are still verified
+func NewHasher(cfg config.HashConfig) (*Hasher, error) {
+	bcryptScheme := &BcryptScheme{Cost: cfg.BcryptCost}
+	argonScheme := &Argon2idScheme{
+		Memory:      cfg.Argon2Memory,
+		Iterations:  cfg.Argon2Iterations,
+		Parallelism: cfg.Argon2Parallelism,
+		SaltLength:  cfg.Argon2SaltLength,
+		KeyLength:   cfg.Argon2KeyLength,
+	}
+	var h *Hasher
+	switch cfg.Algorithm {
+	case "", "bcrypt":
+		h = &Hasher{current: bcryptScheme, schemes: []Scheme{bcryptScheme, argonScheme}}
+	case "argon2id":
+		h = &Hasher{current: argonScheme, schemes: []Scheme{argonScheme, bcryptScheme}}
+	default:
+		return nil, fmt.Errorf("unknown password hash algorithm %q", cfg.Algorithm)
+	}
+	h.Register(&PBKDF2SHA256Scheme{})
+	h.Register(&ScryptScheme{})
+	h.Register(&SHA512Scheme{Pepper: cfg.ImportPepper})
+	return h, nil
+}
+
+// Register adds a scheme that is only used to verify existing hashes
+func (h *Hasher) Register(s Scheme) {
+	h.schemes = append(h.schemes, s)
+}
+
+// Recognizes reports whether an encoded hash belongs to a known scheme
+func (h *Hasher) Recognizes(encoded string) bool {
+	for _, s := range h.schemes {
+		if s.Recognizes(encoded) {
+			return true
+		}
+	}
+	return false
+}
+
+// Hash hashes a password with the current scheme
+func (h *Hasher) Hash(plain string) (string, error) {
+	return h.current.Hash(plain)
+}
+
+// Verify checks a password against an encoded hash of any known scheme.
+// rehash is set when the password is correct but the hash should be
+// replaced by one of the current scheme.
+func (h *Hasher) Verify(encoded, plain string) (ok bool, rehash bool) {
+	for _, s := range h.schemes {
+		if !s.Recognizes(encoded) {
+			continue
+		}
+		ok, err := s.Verify(encoded, plain)
+		if err != nil || !ok {
+			return false, false
+		}
+		return true, s != h.current || s.NeedsRehash(encoded)
+	}
+	return false, false
+}
+
+var defaultHasher, _ = NewHasher(config.HashConfig{})
+
+// SetHasher sets the hasher used by Hash and Verify
+func SetHasher(h *Hasher) {
+	defaultHasher = h
+}
+
+// Hash hashes a password with the default hasher
+func Hash(plain string) (string, error) {
+	return defaultHasher.Hash(plain)
+}
+
+// Recognizes reports whether the default hasher can verify a hash
+func Recognizes(encoded string) bool {
+	return defaultHasher.Recognizes(encoded)
+}
+
+// Verify checks a password with the default hasher
+func Verify(encoded, plain string) (ok bool, rehash bool) {
+	return defaultHasher.Verify(encoded, plain)
+}
+
+// BcryptScheme hashes with bcrypt
+type BcryptScheme struct {
+	Cost int
+}
+
+func (s *BcryptScheme) cost() int {
+	if s.Cost == 0 {
+		return bcrypt.DefaultCost
+	}
+	return s.Cost
+}
+
+func (s *BcryptScheme) Recognizes(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") ||
+		strings.HasPrefix(encoded, "$2b$") ||
+		strings.HasPrefix(encoded, "$2y$")
+}
+
+func (s *BcryptScheme) Hash(plain string) (string, error) {
+	h, err := bcrypt.GenerateFromPassword([]byte(plain), s.cost())
+	if err != nil {
+		return "", err
+	}
+	return string(h), nil
+}
+
+func (s *BcryptScheme) Verify(encoded, plain string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(plain))
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *BcryptScheme) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost < s.cost()
+}
+
+// Argon2idScheme hashes with Argon2id and encodes hashes in the PHC string
+// format: $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>
+type Argon2idScheme struct {
+	// Memory in KiB
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+type argon2Params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt        []byte
+	key         []byte
+}
+
+func (s *Argon2idScheme) params() argon2Params {
+	p := argon2Params{
+		memory:      s.Memory,
+		iterations:  s.Iterations,
+		parallelism: s.Parallelism,
+	}
+	if p.memory == 0 {
+		p.memory = 64 * 1024
+	}
+	if p.iterations == 0 {
+		p.iterations = 3
+	}
+	if p.parallelism == 0 {
+		p.parallelism = 2
+	}
+	return p
+}
+
+func (s *Argon2idScheme) saltLength() uint32 {
+	if s.SaltLength == 0 {
+		return 16
+	}
+	return s.SaltLength
+}
+
+func (s *Argon2idScheme) keyLength() uint32 {
+	if s.KeyLength == 0 {
+		return 32
+	}
+	return s.KeyLength
+}
+
+func (s *Argon2idScheme) Recognizes(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$")
+}
+
+func (s *Argon2idScheme) Hash(plain string) (string, error) {
+	p := s.params()
+	p.salt = make([]byte, s.saltLength())
+	if _, err := rand.Read(p.salt); err != nil {
+		return "", err
+	}
+	p.key = argon2.IDKey([]byte(plain), p.salt, p.iterations, p.memory, p.parallelism, s.keyLength())
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		p.memory,
+		p.iterations,
+		p.parallelism,
+		base64.RawStdEncoding.EncodeToString(p.salt),
+		base64.RawStdEncoding.EncodeToString(p.key),
+	), nil
+}
+
+func (s *Argon2idScheme) Verify(encoded, plain string) (bool, error) {
+	p, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+	key := argon2.IDKey([]byte(plain), p.salt, p.iterations, p.memory, p.parallelism, uint32(len(p.key)))
+	return subtle.ConstantTimeCompare(key, p.key) == 1, nil
+}
+
+func (s *Argon2idScheme) NeedsRehash(encoded string) bool {
+	p, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	want := s.params()
+	return p.memory < want.memory ||
+		p.iterations < want.iterations ||
+		p.parallelism < want.parallelism ||
+		uint32(len(p.key)) < s.keyLength()
+}
+
+func decodeArgon2id(encoded string) (argon2Params, error) {
+	var p argon2Params
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return p, errors.New("invalid argon2id hash")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, errors.New("unsupported argon2id version")
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.iterations, &p.parallelism); err != nil {
+		return p, errors.New("invalid argon2id parameters")
+	}
+	var err error
+	if p.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return p, errors.New("invalid argon2id salt")
+	}
+	if p.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(p.key) == 0 {
+		return p, errors.New("invalid argon2id key")
+	}
+	return p, nil
+}
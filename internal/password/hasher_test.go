@@ -0,0 +1,94 @@
+package password
+
+import (
+	"testing"
+
+	"github.com/rezaAmiri123/service-user/cmd/config"
+)
+
+// fastArgon2 keeps argon2id hashing cheap in tests
+var fastArgon2 = config.HashConfig{
+	Argon2Memory:      1024,
+	Argon2Iterations:  1,
+	Argon2Parallelism: 1,
+}
+
+func newTestHasher(t *testing.T, cfg config.HashConfig) *Hasher {
+	t.Helper()
+	h, err := NewHasher(cfg)
+	if err != nil {
+		t.Fatalf("NewHasher: %v", err)
+	}
+	return h
+}
+
+func TestNewHasherUnknownAlgorithm(t *testing.T) {
+	if _, err := NewHasher(config.HashConfig{Algorithm: "md5"}); err == nil {
+		t.Fatal("NewHasher accepted an unknown algorithm")
+	}
+}
+
+func TestHasherVerify(t *testing.T) {
+	bcryptCfg := config.HashConfig{Algorithm: "bcrypt", BcryptCost: 4}
+	argonCfg := fastArgon2
+	argonCfg.Algorithm = "argon2id"
+	strongerArgon := argonCfg
+	strongerArgon.Argon2Iterations = 2
+
+	tests := []struct {
+		name       string
+		hashedWith config.HashConfig
+		verifyWith config.HashConfig
+		password   string
+		wantOK     bool
+		wantRehash bool
+	}{
+		{"bcrypt", bcryptCfg, bcryptCfg, "secret", true, false},
+		{"bcrypt wrong password", bcryptCfg, bcryptCfg, "wrong", false, false},
+		{"argon2id", argonCfg, argonCfg, "secret", true, false},
+		{"argon2id wrong password", argonCfg, argonCfg, "wrong", false, false},
+		{"bcrypt hash with argon2id current", bcryptCfg, argonCfg, "secret", true, true},
+		{"argon2id hash with bcrypt current", argonCfg, bcryptCfg, "secret", true, true},
+		{"bcrypt cost raised", bcryptCfg, config.HashConfig{Algorithm: "bcrypt", BcryptCost: 5}, "secret", true, true},
+		{"argon2id iterations raised", argonCfg, strongerArgon, "secret", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := newTestHasher(t, tt.hashedWith).Hash("secret")
+			if err != nil {
+				t.Fatalf("Hash: %v", err)
+			}
+			h := newTestHasher(t, tt.verifyWith)
+			if !h.Recognizes(encoded) {
+				t.Fatalf("Recognizes(%q) = false", encoded)
+			}
+			ok, rehash := h.Verify(encoded, tt.password)
+			if ok != tt.wantOK || rehash != tt.wantRehash {
+				t.Errorf("Verify = %v, %v, want %v, %v", ok, rehash, tt.wantOK, tt.wantRehash)
+			}
+		})
+	}
+}
+
+func TestHasherRejectsInvalidHashes(t *testing.T) {
+	h := newTestHasher(t, fastArgon2)
+	tests := []struct {
+		name    string
+		encoded string
+	}{
+		{"empty", ""},
+		{"plain text", "secret"},
+		{"unknown scheme", "$md5$abc$def"},
+		{"truncated bcrypt", "$2a$10$abc"},
+		{"argon2id missing key", "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA"},
+		{"argon2id wrong version", "$argon2id$v=16$m=1024,t=1,p=1$c2FsdA$a2V5"},
+		{"argon2id bad salt", "$argon2id$v=19$m=1024,t=1,p=1$!!$a2V5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ok, rehash := h.Verify(tt.encoded, "secret"); ok || rehash {
+				t.Errorf("Verify(%q) = %v, %v, want false, false", tt.encoded, ok, rehash)
+			}
+		})
+	}
+}
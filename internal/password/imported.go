@@ -0,0 +1,195 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// The schemes below verify hashes imported from other identity providers.
+// They are never the current scheme, so imported hashes are replaced on the
+// first successful login.
+
+// PBKDF2SHA256Scheme verifies $pbkdf2-sha256$i=<iterations>$<salt>$<key>
+type PBKDF2SHA256Scheme struct {
+	Iterations int
+}
+
+// EncodePBKDF2SHA256 returns the tagged form of a PBKDF2-SHA256 hash
+func EncodePBKDF2SHA256(iterations int, salt, key []byte) string {
+	return fmt.Sprintf("$pbkdf2-sha256$i=%d$%s$%s", iterations, b64(salt), b64(key))
+}
+
+func (s *PBKDF2SHA256Scheme) Recognizes(encoded string) bool {
+	return strings.HasPrefix(encoded, "$pbkdf2-sha256$")
+}
+
+func (s *PBKDF2SHA256Scheme) Hash(plain string) (string, error) {
+	salt, err := newSalt()
+	if err != nil {
+		return "", err
+	}
+	iterations := s.Iterations
+	if iterations == 0 {
+		iterations = 310000
+	}
+	return EncodePBKDF2SHA256(iterations, salt, pbkdf2.Key([]byte(plain), salt, iterations, sha256.Size, sha256.New)), nil
+}
+
+func (s *PBKDF2SHA256Scheme) Verify(encoded, plain string) (bool, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 5 {
+		return false, errors.New("invalid pbkdf2-sha256 hash")
+	}
+	var iterations int
+	if _, err := fmt.Sscanf(parts[2], "i=%d", &iterations); err != nil || iterations <= 0 {
+		return false, errors.New("invalid pbkdf2-sha256 iterations")
+	}
+	salt, key, err := decodeSaltKey(parts[3], parts[4])
+	if err != nil {
+		return false, err
+	}
+	got := pbkdf2.Key([]byte(plain), salt, iterations, len(key), sha256.New)
+	return subtle.ConstantTimeCompare(got, key) == 1, nil
+}
+
+func (s *PBKDF2SHA256Scheme) NeedsRehash(encoded string) bool {
+	return true
+}
+
+// ScryptScheme verifies $scrypt$ln=<log2 N>,r=<r>,p=<p>$<salt>$<key>
+type ScryptScheme struct{}
+
+// EncodeScrypt returns the tagged form of a scrypt hash; n must be a power
+// of two
+func EncodeScrypt(n, r, p int, salt, key []byte) (string, error) {
+	ln := 0
+	for v := n; v > 1; v >>= 1 {
+		if v&1 != 0 {
+			return "", errors.New("scrypt N must be a power of two")
+		}
+		ln++
+	}
+	if ln == 0 || r <= 0 || p <= 0 {
+		return "", errors.New("invalid scrypt parameters")
+	}
+	return fmt.Sprintf("$scrypt$ln=%d,r=%d,p=%d$%s$%s", ln, r, p, b64(salt), b64(key)), nil
+}
+
+func (s *ScryptScheme) Recognizes(encoded string) bool {
+	return strings.HasPrefix(encoded, "$scrypt$")
+}
+
+func (s *ScryptScheme) Hash(plain string) (string, error) {
+	salt, err := newSalt()
+	if err != nil {
+		return "", err
+	}
+	key, err := scrypt.Key([]byte(plain), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return "", err
+	}
+	return EncodeScrypt(1<<15, 8, 1, salt, key)
+}
+
+func (s *ScryptScheme) Verify(encoded, plain string) (bool, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 5 {
+		return false, errors.New("invalid scrypt hash")
+	}
+	var ln, r, p int
+	if _, err := fmt.Sscanf(parts[2], "ln=%d,r=%d,p=%d", &ln, &r, &p); err != nil || ln <= 0 || ln > 30 {
+		return false, errors.New("invalid scrypt parameters")
+	}
+	salt, key, err := decodeSaltKey(parts[3], parts[4])
+	if err != nil {
+		return false, err
+	}
+	got, err := scrypt.Key([]byte(plain), salt, 1<<uint(ln), r, p, len(key))
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare(got, key) == 1, nil
+}
+
+func (s *ScryptScheme) NeedsRehash(encoded string) bool {
+	return true
+}
+
+// SHA512Scheme verifies $sha512$<salt>$<digest> where digest is
+// SHA-512(salt || password || pepper) and the pepper is a secret shared by
+// every imported hash
+type SHA512Scheme struct {
+	Pepper string
+}
+
+// EncodeSHA512 returns the tagged form of a salted and peppered SHA-512 hash
+func EncodeSHA512(salt, digest []byte) string {
+	return fmt.Sprintf("$sha512$%s$%s", b64(salt), b64(digest))
+}
+
+func (s *SHA512Scheme) Recognizes(encoded string) bool {
+	return strings.HasPrefix(encoded, "$sha512$")
+}
+
+func (s *SHA512Scheme) Hash(plain string) (string, error) {
+	salt, err := newSalt()
+	if err != nil {
+		return "", err
+	}
+	return EncodeSHA512(salt, s.digest(salt, plain)), nil
+}
+
+func (s *SHA512Scheme) Verify(encoded, plain string) (bool, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 4 {
+		return false, errors.New("invalid sha512 hash")
+	}
+	salt, digest, err := decodeSaltKey(parts[2], parts[3])
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare(s.digest(salt, plain), digest) == 1, nil
+}
+
+func (s *SHA512Scheme) NeedsRehash(encoded string) bool {
+	return true
+}
+
+func (s *SHA512Scheme) digest(salt []byte, plain string) []byte {
+	h := sha512.New()
+	h.Write(salt)
+	h.Write([]byte(plain))
+	h.Write([]byte(s.Pepper))
+	return h.Sum(nil)
+}
+
+func newSalt() ([]byte, error) {
+	salt := make([]byte, 16)
+	_, err := rand.Read(salt)
+	return salt, err
+}
+
+func b64(b []byte) string {
+	return base64.RawStdEncoding.EncodeToString(b)
+}
+
+func decodeSaltKey(salt, key string) ([]byte, []byte, error) {
+	s, err := base64.RawStdEncoding.DecodeString(salt)
+	if err != nil {
+		return nil, nil, errors.New("invalid salt")
+	}
+	k, err := base64.RawStdEncoding.DecodeString(key)
+	if err != nil || len(k) == 0 {
+		return nil, nil, errors.New("invalid hash")
+	}
+	return s, k, nil
+}
@@ -0,0 +1,83 @@
+package password
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"testing"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+func TestImportedSchemes(t *testing.T) {
+	salt := []byte("0123456789abcdef")
+	scryptKey, err := scrypt.Key([]byte("secret"), salt, 1<<4, 8, 1, 32)
+	if err != nil {
+		t.Fatalf("scrypt: %v", err)
+	}
+	scryptHash, err := EncodeScrypt(1<<4, 8, 1, salt, scryptKey)
+	if err != nil {
+		t.Fatalf("EncodeScrypt: %v", err)
+	}
+	digest := sha512.Sum512(append(append(append([]byte{}, salt...), "secret"...), "pepper"...))
+
+	tests := []struct {
+		name     string
+		encoded  string
+		pepper   string
+		password string
+		wantOK   bool
+	}{
+		{"pbkdf2-sha256", EncodePBKDF2SHA256(1000, salt, pbkdf2.Key([]byte("secret"), salt, 1000, sha256.Size, sha256.New)), "", "secret", true},
+		{"pbkdf2-sha256 wrong password", EncodePBKDF2SHA256(1000, salt, pbkdf2.Key([]byte("secret"), salt, 1000, sha256.Size, sha256.New)), "", "wrong", false},
+		{"pbkdf2-sha256 zero iterations", "$pbkdf2-sha256$i=0$c2FsdA$a2V5", "", "secret", false},
+		{"scrypt", scryptHash, "", "secret", true},
+		{"scrypt wrong password", scryptHash, "", "wrong", false},
+		{"scrypt bad parameters", "$scrypt$ln=99,r=8,p=1$c2FsdA$a2V5", "", "secret", false},
+		{"sha512", EncodeSHA512(salt, digest[:]), "pepper", "secret", true},
+		{"sha512 wrong password", EncodeSHA512(salt, digest[:]), "pepper", "wrong", false},
+		{"sha512 wrong pepper", EncodeSHA512(salt, digest[:]), "other", "secret", false},
+		{"sha512 missing digest", "$sha512$c2FsdA", "pepper", "secret", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := fastArgon2
+			cfg.ImportPepper = tt.pepper
+			h := newTestHasher(t, cfg)
+			if !h.Recognizes(tt.encoded) {
+				t.Fatalf("Recognizes(%q) = false", tt.encoded)
+			}
+			ok, rehash := h.Verify(tt.encoded, tt.password)
+			// imported hashes are always replaced after a successful login
+			if ok != tt.wantOK || rehash != tt.wantOK {
+				t.Errorf("Verify = %v, %v, want %v, %v", ok, rehash, tt.wantOK, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestEncodeScryptParameters(t *testing.T) {
+	tests := []struct {
+		name    string
+		n, r, p int
+		want    string
+		wantErr bool
+	}{
+		{"valid", 1 << 15, 8, 1, "$scrypt$ln=15,r=8,p=1$c2FsdA$a2V5", false},
+		{"n not a power of two", 1000, 8, 1, "", true},
+		{"n of one", 1, 8, 1, "", true},
+		{"zero r", 1 << 15, 0, 1, "", true},
+		{"zero p", 1 << 15, 8, 0, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EncodeScrypt(tt.n, tt.r, tt.p, []byte("salt"), []byte("key"))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EncodeScrypt error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("EncodeScrypt = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rezaAmiri123/service-user/cmd/config"
+)
+
+// Violation is a password policy rule the password does not meet
+type Violation struct {
+	Rule        string
+	Description string
+}
+
+// Policy checks new passwords against the configured rules
+type Policy struct {
+	cfg config.PasswordConfig
+	// breached holds upper case hex SHA-1 prefixes of breached passwords
+	breached  map[string]struct{}
+	prefixLen int
+}
+
+// NewPolicy returns the policy described by cfg, loading the breached
+// password list when one is configured
+func NewPolicy(cfg config.PasswordConfig) (*Policy, error) {
+	p := &Policy{cfg: cfg}
+	if cfg.BreachedListFile != "" {
+		if err := p.loadBreached(cfg.BreachedListFile); err != nil {
+			return nil, fmt.Errorf("load breached password list: %w", err)
+		}
+	}
+	return p, nil
+}
+
+// loadBreached reads a file of hex SHA-1 hashes of breached passwords, one
+// per line and optionally followed by ":count". Hashes may be truncated to
+// save memory, but every line must use the same prefix length.
+func (p *Policy) loadBreached(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	p.breached = make(map[string]struct{})
+	scanner := bufio.NewScanner(f)
+	line := 0
+	for scanner.Scan() {
+		line++
+		prefix := strings.TrimSpace(scanner.Text())
+		if i := strings.IndexByte(prefix, ':'); i >= 0 {
+			prefix = prefix[:i]
+		}
+		if prefix == "" || strings.HasPrefix(prefix, "#") {
+			continue
+		}
+		if _, err := hex.DecodeString(prefix + strings.Repeat("0", len(prefix)%2)); err != nil {
+			return fmt.Errorf("line %d: not a hex hash", line)
+		}
+		if p.prefixLen == 0 {
+			p.prefixLen = len(prefix)
+		}
+		if len(prefix) != p.prefixLen || p.prefixLen > sha1.Size*2 {
+			return fmt.Errorf("line %d: want a %d character hash prefix", line, p.prefixLen)
+		}
+		p.breached[strings.ToUpper(prefix)] = struct{}{}
+	}
+	return scanner.Err()
+}
+
+// Validate returns the rules the password breaks for the given user
+func (p *Policy) Validate(password, username, email string) []Violation {
+	var violations []Violation
+	add := func(rule, format string, args ...interface{}) {
+		violations = append(violations, Violation{Rule: rule, Description: fmt.Sprintf(format, args...)})
+	}
+
+	if n := utf8.RuneCountInString(password); n < p.minLength() {
+		add("min_length", "must be at least %d characters long", p.minLength())
+	} else if p.cfg.MaxLength > 0 && n > p.cfg.MaxLength {
+		add("max_length", "must be at most %d characters long", p.cfg.MaxLength)
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.cfg.RequireUpper && !upper {
+		add("upper", "must contain an upper case letter")
+	}
+	if p.cfg.RequireLower && !lower {
+		add("lower", "must contain a lower case letter")
+	}
+	if p.cfg.RequireDigit && !digit {
+		add("digit", "must contain a digit")
+	}
+	if p.cfg.RequireSymbol && !symbol {
+		add("symbol", "must contain a symbol")
+	}
+
+	if p.cfg.DisallowUserInfo && containsUserInfo(password, username, email) {
+		add("user_info", "must not contain the username or email")
+	}
+
+	if p.isBreached(password) {
+		add("breached", "appears in a list of breached passwords, choose another one")
+	}
+	return violations
+}
+
+func (p *Policy) minLength() int {
+	if p.cfg.MinLength > 0 {
+		return p.cfg.MinLength
+	}
+	return 1
+}
+
+func (p *Policy) isBreached(password string) bool {
+	if len(p.breached) == 0 {
+		return false
+	}
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	_, ok := p.breached[hash[:p.prefixLen]]
+	return ok
+}
+
+// containsUserInfo reports whether the password contains the username or
+// the local part of the email, ignoring case. Very short values are
+// ignored since they match too many passwords.
+func containsUserInfo(password, username, email string) bool {
+	const minLen = 3
+	pw := strings.ToLower(password)
+	local := email
+	if i := strings.LastIndexByte(email, '@'); i >= 0 {
+		local = email[:i]
+	}
+	for _, v := range []string{username, local} {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if len(v) >= minLen && strings.Contains(pw, v) {
+			return true
+		}
+	}
+	return false
+}
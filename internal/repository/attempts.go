@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/opentracing/opentracing-go"
+)
+
+// AttemptRepository counts failed login attempts and keeps temporary locks
+type AttemptRepository interface {
+	// Incr counts a failure; the counter is dropped window after the
+	// first failure it counts
+	Incr(ctx context.Context, key string, window time.Duration) (int64, error)
+	Count(ctx context.Context, key string) (int64, error)
+	Reset(ctx context.Context, key string) error
+	Lock(ctx context.Context, key string, d time.Duration) error
+	// LockTTL returns how long the key stays locked, zero when unlocked
+	LockTTL(ctx context.Context, key string) (time.Duration, error)
+	Unlock(ctx context.Context, key string) error
+}
+
+type attemptRedisRepo struct {
+	redisClient *redis.Client
+	basePrefix  string
+}
+
+func NewAttemptRedisRepo(redisClient *redis.Client, basePrefix string) *attemptRedisRepo {
+	return &attemptRedisRepo{redisClient: redisClient, basePrefix: basePrefix}
+}
+
+// incrScript counts a failure and starts the window on the first one in a
+// single step, so a counter is never left without an expiry. A counter
+// left without one by an earlier, non-atomic write gets one as well.
+var incrScript = redis.NewScript(`
+local n = redis.call("INCR", KEYS[1])
+if n == 1 or redis.call("PTTL", KEYS[1]) == -1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return n
+`)
+
+func (r *attemptRedisRepo) Incr(ctx context.Context, key string, window time.Duration) (int64, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "attemptRedisRepo.Incr")
+	defer span.Finish()
+
+	k := r.createKey("count", key)
+	return incrScript.Run(ctx, r.redisClient, []string{k}, window.Milliseconds()).Int64()
+}
+
+func (r *attemptRedisRepo) Count(ctx context.Context, key string) (int64, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "attemptRedisRepo.Count")
+	defer span.Finish()
+
+	n, err := r.redisClient.Get(ctx, r.createKey("count", key)).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return n, err
+}
+
+func (r *attemptRedisRepo) Reset(ctx context.Context, key string) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "attemptRedisRepo.Reset")
+	defer span.Finish()
+
+	return r.redisClient.Del(ctx, r.createKey("count", key)).Err()
+}
+
+func (r *attemptRedisRepo) Lock(ctx context.Context, key string, d time.Duration) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "attemptRedisRepo.Lock")
+	defer span.Finish()
+
+	return r.redisClient.Set(ctx, r.createKey("lock", key), 1, d).Err()
+}
+
+func (r *attemptRedisRepo) LockTTL(ctx context.Context, key string) (time.Duration, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "attemptRedisRepo.LockTTL")
+	defer span.Finish()
+
+	ttl, err := r.redisClient.PTTL(ctx, r.createKey("lock", key)).Result()
+	if err != nil {
+		return 0, err
+	}
+	// negative values mean the key does not exist or never expires
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (r *attemptRedisRepo) Unlock(ctx context.Context, key string) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "attemptRedisRepo.Unlock")
+	defer span.Finish()
+
+	return r.redisClient.Del(ctx, r.createKey("lock", key), r.createKey("count", key)).Err()
+}
+
+func (r *attemptRedisRepo) createKey(kind, value string) string {
+	return fmt.Sprintf("%s%s: %s", r.basePrefix, kind, value)
+}
+
+type expiringValue struct {
+	n         int64
+	expiresAt time.Time
+}
+
+// InMemoryAttemptRepo is an AttemptRepository for tests and for running
+// without Redis
+type InMemoryAttemptRepo struct {
+	mu     sync.Mutex
+	counts map[string]expiringValue
+	locks  map[string]time.Time
+}
+
+func NewInMemoryAttemptRepo() *InMemoryAttemptRepo {
+	return &InMemoryAttemptRepo{
+		counts: make(map[string]expiringValue),
+		locks:  make(map[string]time.Time),
+	}
+}
+
+func (r *InMemoryAttemptRepo) Incr(ctx context.Context, key string, window time.Duration) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.sweep(now)
+	v, ok := r.counts[key]
+	if !ok || now.After(v.expiresAt) {
+		v = expiringValue{expiresAt: now.Add(window)}
+	}
+	v.n++
+	r.counts[key] = v
+	return v.n, nil
+}
+
+func (r *InMemoryAttemptRepo) Count(ctx context.Context, key string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	v, ok := r.counts[key]
+	if !ok || time.Now().After(v.expiresAt) {
+		return 0, nil
+	}
+	return v.n, nil
+}
+
+func (r *InMemoryAttemptRepo) Reset(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.counts, key)
+	return nil
+}
+
+func (r *InMemoryAttemptRepo) Lock(ctx context.Context, key string, d time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.locks[key] = time.Now().Add(d)
+	return nil
+}
+
+func (r *InMemoryAttemptRepo) LockTTL(ctx context.Context, key string) (time.Duration, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	until, ok := r.locks[key]
+	if !ok {
+		return 0, nil
+	}
+	ttl := time.Until(until)
+	if ttl <= 0 {
+		delete(r.locks, key)
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (r *InMemoryAttemptRepo) Unlock(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.locks, key)
+	delete(r.counts, key)
+	return nil
+}
+
+// sweep drops expired counters and locks; callers must hold mu
+func (r *InMemoryAttemptRepo) sweep(now time.Time) {
+	for k, v := range r.counts {
+		if now.After(v.expiresAt) {
+			delete(r.counts, k)
+		}
+	}
+	for k, until := range r.locks {
+		if now.After(until) {
+			delete(r.locks, k)
+		}
+	}
+}
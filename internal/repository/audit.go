@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/rezaAmiri123/service-user/internal/model"
+)
+
+// AuditFilter selects audit events for List. Events are ordered newest
+// first and only events with an id lower than BeforeID are returned when it
+// is set.
+type AuditFilter struct {
+	TargetUserID uint
+	ActorID      uint
+	BeforeID     uint
+	Limit        int
+}
+
+type AuditRepository interface {
+	Record(ctx context.Context, event *model.AuditEvent) error
+	RecordAction(ctx context.Context, event *model.AuditEvent, action func() error) error
+	List(ctx context.Context, filter AuditFilter) ([]*model.AuditEvent, error)
+}
+
+type ORMAuditRepository struct {
+	db *gorm.DB
+}
+
+func NewORMAuditRepository(db *gorm.DB) *ORMAuditRepository {
+	return &ORMAuditRepository{db: db}
+}
+
+// Record stores an audit event
+func (repo *ORMAuditRepository) Record(ctx context.Context, event *model.AuditEvent) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "AuditRepository.Record")
+	defer span.Finish()
+
+	return repo.db.Create(event).Error
+}
+
+// RecordAction runs an action kept outside of the database, e.g. in Redis,
+// inside the transaction storing its audit event. The event is only kept
+// when the action succeeds and the action only runs once the event is
+// stored.
+func (repo *ORMAuditRepository) RecordAction(ctx context.Context, event *model.AuditEvent, action func() error) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "AuditRepository.RecordAction")
+	defer span.Finish()
+
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := recordEvent(tx, event); err != nil {
+			return err
+		}
+		return action()
+	})
+}
+
+// recordEvent stores an audit event as part of a transaction; a nil event
+// is skipped
+func recordEvent(tx *gorm.DB, event *model.AuditEvent) error {
+	if event == nil {
+		return nil
+	}
+	return tx.Create(event).Error
+}
+
+// List finds audit events matching a filter
+func (repo *ORMAuditRepository) List(ctx context.Context, filter AuditFilter) ([]*model.AuditEvent, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "AuditRepository.List")
+	defer span.Finish()
+
+	q := repo.db
+	if filter.TargetUserID != 0 {
+		q = q.Where("target_user_id = ?", filter.TargetUserID)
+	}
+	if filter.ActorID != 0 {
+		q = q.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.BeforeID != 0 {
+		q = q.Where("id < ?", filter.BeforeID)
+	}
+	var events []*model.AuditEvent
+	err := q.Order("id desc").Limit(filter.Limit).Find(&events).Error
+	return events, err
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/rezaAmiri123/service-user/internal/model"
+	"github.com/rezaAmiri123/service-user/pkg/utils"
+)
+
+type BlockRepository interface {
+	Block(ctx context.Context, blockerID, blockedID uint) error
+	Unblock(ctx context.Context, blockerID, blockedID uint) error
+	BlockStatus(ctx context.Context, userID, otherID uint) (blocking bool, blockedBy bool, err error)
+	BlockedAmong(ctx context.Context, userID uint, ids []uint) (map[uint]bool, error)
+	ListBlocked(ctx context.Context, userID, afterID uint, limit int) ([]*model.User, error)
+}
+
+type ORMBlockRepository struct {
+	db        *gorm.DB
+	cacheRepo UserCacheRepository
+}
+
+func NewORMBlockRepository(db *gorm.DB, cacheRepo UserCacheRepository) *ORMBlockRepository {
+	return &ORMBlockRepository{db: db, cacheRepo: cacheRepo}
+}
+
+// Block blocks a user and removes the follows and follow requests between
+// both users
+func (repo *ORMBlockRepository) Block(ctx context.Context, blockerID, blockedID uint) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "BlockRepository.Block")
+	defer span.Finish()
+
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		query := fmt.Sprintf(
+			"INSERT INTO blocks (blocker_id, blocked_id, created_at) SELECT ?, ?, ? %s "+
+				"WHERE NOT EXISTS (SELECT * FROM blocks WHERE blocker_id = ? AND blocked_id = ?)",
+			tx.Dialect().SelectFromDummyTable(),
+		)
+		if err := tx.Exec(query, blockerID, blockedID, gorm.NowFunc(), blockerID, blockedID).Error; err != nil {
+			return err
+		}
+		err := tx.Where("(from_user_id = ? AND to_user_id = ?) OR (from_user_id = ? AND to_user_id = ?)",
+			blockerID, blockedID, blockedID, blockerID).
+			Delete(&model.FollowRequest{}).Error
+		if err != nil {
+			return err
+		}
+		if err := deleteFollow(tx, blockerID, blockedID); err != nil {
+			return err
+		}
+		return deleteFollow(tx, blockedID, blockerID)
+	})
+	repo.cacheRepo.DeleteByID(ctx, utils.UintToString(blockerID))
+	repo.cacheRepo.DeleteByID(ctx, utils.UintToString(blockedID))
+	return err
+}
+
+// Unblock removes a block, it returns gorm.ErrRecordNotFound when the user
+// is not blocked
+func (repo *ORMBlockRepository) Unblock(ctx context.Context, blockerID, blockedID uint) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "BlockRepository.Unblock")
+	defer span.Finish()
+
+	res := repo.db.Where("blocker_id = ? AND blocked_id = ?", blockerID, blockedID).Delete(&model.Block{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// BlockStatus reports whether a user blocks another user and whether the
+// other user blocks them
+func (repo *ORMBlockRepository) BlockStatus(ctx context.Context, userID, otherID uint) (bool, bool, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "BlockRepository.BlockStatus")
+	defer span.Finish()
+
+	var blocks []*model.Block
+	err := repo.db.Where("(blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)",
+		userID, otherID, otherID, userID).
+		Find(&blocks).Error
+	if err != nil {
+		return false, false, err
+	}
+	var blocking, blockedBy bool
+	for _, b := range blocks {
+		if b.BlockerID == userID {
+			blocking = true
+		} else {
+			blockedBy = true
+		}
+	}
+	return blocking, blockedBy, nil
+}
+
+// BlockedAmong returns the ids among ids of the users a user blocks or is
+// blocked by, in one query
+func (repo *ORMBlockRepository) BlockedAmong(ctx context.Context, userID uint, ids []uint) (map[uint]bool, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "BlockRepository.BlockedAmong")
+	defer span.Finish()
+
+	blocked := make(map[uint]bool)
+	if len(ids) == 0 {
+		return blocked, nil
+	}
+	var blocks []*model.Block
+	err := repo.db.Where("(blocker_id = ? AND blocked_id IN (?)) OR (blocked_id = ? AND blocker_id IN (?))",
+		userID, ids, userID, ids).
+		Find(&blocks).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range blocks {
+		if b.BlockerID == userID {
+			blocked[b.BlockedID] = true
+		} else {
+			blocked[b.BlockerID] = true
+		}
+	}
+	return blocked, nil
+}
+
+// ListBlocked finds the users a user blocks, ordered by id, starting after
+// afterID
+func (repo *ORMBlockRepository) ListBlocked(ctx context.Context, userID, afterID uint, limit int) ([]*model.User, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "BlockRepository.ListBlocked")
+	defer span.Finish()
+
+	var users []*model.User
+	err := repo.db.Joins("JOIN blocks ON blocks.blocked_id = users.id").
+		Where("blocks.blocker_id = ? AND users.id > ?", userID, afterID).
+		Order("users.id").
+		Limit(limit).
+		Find(&users).Error
+	return users, err
+}
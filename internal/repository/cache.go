@@ -17,7 +17,9 @@ import (
 // UserCacheRepository
 type UserCacheRepository interface {
 	GetByID(ctx context.Context, key string) (*model.User, error)
+	GetByIDs(ctx context.Context, keys []string) (map[string]*model.User, error)
 	SetByID(ctx context.Context, key string, seconds int, user *model.User) error
+	SetByIDs(ctx context.Context, seconds int, users map[string]*model.User) error
 	DeleteByID(ctx context.Context, key string) error
 }
 
@@ -50,6 +52,38 @@ func (r *userRedisRepo) GetByID(ctx context.Context, key string) (*model.User, e
 	return user, nil
 }
 
+// GetByIDs returns the cached users of keys in one round trip; keys that
+// are not cached are missing from the result
+func (r *userRedisRepo) GetByIDs(ctx context.Context, keys []string) (map[string]*model.User, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "userRedisRepo.GetByIDs")
+	defer span.Finish()
+
+	users := make(map[string]*model.User, len(keys))
+	if len(keys) == 0 {
+		return users, nil
+	}
+	redisKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		redisKeys = append(redisKeys, r.createKey(key))
+	}
+	values, err := r.redisClient.MGet(ctx, redisKeys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, value := range values {
+		s, ok := value.(string)
+		if !ok {
+			continue
+		}
+		user := &model.User{}
+		if err := json.Unmarshal([]byte(s), user); err != nil {
+			continue
+		}
+		users[keys[i]] = user
+	}
+	return users, nil
+}
+
 func (r *userRedisRepo) SetByID(ctx context.Context, key string, seconds int, user *model.User) error {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "userRedisRepo.SetByID")
 	defer span.Finish()
@@ -61,6 +95,26 @@ func (r *userRedisRepo) SetByID(ctx context.Context, key string, seconds int, us
 	return r.redisClient.Set(ctx, r.createKey(key), userBytes, time.Second*time.Duration(seconds)).Err()
 }
 
+// SetByIDs caches users by key in one round trip
+func (r *userRedisRepo) SetByIDs(ctx context.Context, seconds int, users map[string]*model.User) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "userRedisRepo.SetByIDs")
+	defer span.Finish()
+
+	if len(users) == 0 {
+		return nil
+	}
+	pipe := r.redisClient.Pipeline()
+	for key, user := range users {
+		userBytes, err := json.Marshal(user)
+		if err != nil {
+			return err
+		}
+		pipe.Set(ctx, r.createKey(key), userBytes, time.Second*time.Duration(seconds))
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
 func (r *userRedisRepo) DeleteByID(ctx context.Context, key string) error {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "userRedisRepo.DeleteByID")
 	defer span.Finish()
@@ -71,3 +125,58 @@ func (r *userRedisRepo) DeleteByID(ctx context.Context, key string) error {
 func (r *userRedisRepo) createKey(value string) string {
 	return fmt.Sprintf("%s: %s", r.basePrefix, value)
 }
+
+// SessionCacheRepository caches the ids of active sessions
+type SessionCacheRepository interface {
+	IsActive(ctx context.Context, sessionID string) (bool, error)
+	SetActive(ctx context.Context, sessionID string, ttl time.Duration) error
+	Delete(ctx context.Context, sessionIDs ...string) error
+}
+
+type sessionRedisRepo struct {
+	redisClient *redis.Client
+	basePrefix  string
+}
+
+func NewSessionRedisRepo(redisClient *redis.Client, basePrefix string) *sessionRedisRepo {
+	return &sessionRedisRepo{redisClient: redisClient, basePrefix: basePrefix}
+}
+
+func (r *sessionRedisRepo) IsActive(ctx context.Context, sessionID string) (bool, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "sessionRedisRepo.IsActive")
+	defer span.Finish()
+
+	n, err := r.redisClient.Exists(ctx, r.createKey(sessionID)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (r *sessionRedisRepo) SetActive(ctx context.Context, sessionID string, ttl time.Duration) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "sessionRedisRepo.SetActive")
+	defer span.Finish()
+
+	if ttl <= 0 {
+		return nil
+	}
+	return r.redisClient.Set(ctx, r.createKey(sessionID), 1, ttl).Err()
+}
+
+func (r *sessionRedisRepo) Delete(ctx context.Context, sessionIDs ...string) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "sessionRedisRepo.Delete")
+	defer span.Finish()
+
+	if len(sessionIDs) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(sessionIDs))
+	for _, id := range sessionIDs {
+		keys = append(keys, r.createKey(id))
+	}
+	return r.redisClient.Del(ctx, keys...).Err()
+}
+
+func (r *sessionRedisRepo) createKey(value string) string {
+	return fmt.Sprintf("%s: %s", r.basePrefix, value)
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/rezaAmiri123/service-user/internal/model"
+	"github.com/rezaAmiri123/service-user/pkg/utils"
+)
+
+type FollowRequestRepository interface {
+	CreateFollowRequest(ctx context.Context, fromUserID, toUserID uint) error
+	DeleteFollowRequest(ctx context.Context, fromUserID, toUserID uint) error
+	ApproveFollowRequest(ctx context.Context, fromUserID, toUserID uint) error
+	ApproveAllFollowRequests(ctx context.Context, toUserID uint) error
+	RequestedAmong(ctx context.Context, userID uint, ids []uint) (map[uint]bool, error)
+	ListFollowRequests(ctx context.Context, toUserID, afterID uint, limit int) ([]*model.User, error)
+}
+
+type ORMFollowRequestRepository struct {
+	db        *gorm.DB
+	cacheRepo UserCacheRepository
+}
+
+func NewORMFollowRequestRepository(db *gorm.DB, cacheRepo UserCacheRepository) *ORMFollowRequestRepository {
+	return &ORMFollowRequestRepository{db: db, cacheRepo: cacheRepo}
+}
+
+// CreateFollowRequest asks to follow a user, unless it was already asked
+func (repo *ORMFollowRequestRepository) CreateFollowRequest(ctx context.Context, fromUserID, toUserID uint) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "FollowRequestRepository.CreateFollowRequest")
+	defer span.Finish()
+
+	query := fmt.Sprintf(
+		"INSERT INTO follow_requests (from_user_id, to_user_id, created_at) SELECT ?, ?, ? %s "+
+			"WHERE NOT EXISTS (SELECT * FROM follow_requests WHERE from_user_id = ? AND to_user_id = ?)",
+		repo.db.Dialect().SelectFromDummyTable(),
+	)
+	return repo.db.Exec(query, fromUserID, toUserID, gorm.NowFunc(), fromUserID, toUserID).Error
+}
+
+// DeleteFollowRequest cancels or rejects a follow request, it returns
+// gorm.ErrRecordNotFound when there is none
+func (repo *ORMFollowRequestRepository) DeleteFollowRequest(ctx context.Context, fromUserID, toUserID uint) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "FollowRequestRepository.DeleteFollowRequest")
+	defer span.Finish()
+
+	return deleteFollowRequest(repo.db, fromUserID, toUserID)
+}
+
+// ApproveFollowRequest turns a follow request into a follow, it returns
+// gorm.ErrRecordNotFound when there is no request
+func (repo *ORMFollowRequestRepository) ApproveFollowRequest(ctx context.Context, fromUserID, toUserID uint) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "FollowRequestRepository.ApproveFollowRequest")
+	defer span.Finish()
+
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := deleteFollowRequest(tx, fromUserID, toUserID); err != nil {
+			return err
+		}
+		return insertFollow(tx, fromUserID, toUserID)
+	})
+	repo.cacheRepo.DeleteByID(ctx, utils.UintToString(fromUserID))
+	repo.cacheRepo.DeleteByID(ctx, utils.UintToString(toUserID))
+	return err
+}
+
+// ApproveAllFollowRequests turns every follow request to a user into a
+// follow, for an account that is no longer private
+func (repo *ORMFollowRequestRepository) ApproveAllFollowRequests(ctx context.Context, toUserID uint) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "FollowRequestRepository.ApproveAllFollowRequests")
+	defer span.Finish()
+
+	var from []uint
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.FollowRequest{}).Where("to_user_id = ?", toUserID).
+			Order("from_user_id").
+			Pluck("from_user_id", &from).Error
+		if err != nil {
+			return err
+		}
+		for _, id := range from {
+			if err := insertFollow(tx, id, toUserID); err != nil {
+				return err
+			}
+		}
+		return tx.Where("to_user_id = ?", toUserID).Delete(&model.FollowRequest{}).Error
+	})
+	repo.cacheRepo.DeleteByID(ctx, utils.UintToString(toUserID))
+	for _, id := range from {
+		repo.cacheRepo.DeleteByID(ctx, utils.UintToString(id))
+	}
+	return err
+}
+
+// RequestedAmong returns the ids among ids of the users a user asked to
+// follow, in one query
+func (repo *ORMFollowRequestRepository) RequestedAmong(ctx context.Context, userID uint, ids []uint) (map[uint]bool, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "FollowRequestRepository.RequestedAmong")
+	defer span.Finish()
+
+	if len(ids) == 0 {
+		return map[uint]bool{}, nil
+	}
+	return idSet(repo.db.Model(&model.FollowRequest{}).
+		Where("from_user_id = ? AND to_user_id IN (?)", userID, ids).
+		Select("to_user_id"))
+}
+
+// ListFollowRequests finds the users asking to follow a user, ordered by
+// id, starting after afterID
+func (repo *ORMFollowRequestRepository) ListFollowRequests(ctx context.Context, toUserID, afterID uint, limit int) ([]*model.User, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "FollowRequestRepository.ListFollowRequests")
+	defer span.Finish()
+
+	var users []*model.User
+	err := repo.db.Joins("JOIN follow_requests ON follow_requests.from_user_id = users.id").
+		Where("follow_requests.to_user_id = ? AND users.id > ?", toUserID, afterID).
+		Order("users.id").
+		Limit(limit).
+		Find(&users).Error
+	return users, err
+}
+
+func deleteFollowRequest(tx *gorm.DB, fromUserID, toUserID uint) error {
+	res := tx.Where("from_user_id = ? AND to_user_id = ?", fromUserID, toUserID).Delete(&model.FollowRequest{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
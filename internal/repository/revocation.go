@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/opentracing/opentracing-go"
+)
+
+// RevocationRepository keeps revoked access token ids (jti) until the
+// tokens would have expired anyway
+type RevocationRepository interface {
+	Revoke(ctx context.Context, jti string, ttl time.Duration) error
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+type revocationRedisRepo struct {
+	redisClient *redis.Client
+	basePrefix  string
+}
+
+func NewRevocationRedisRepo(redisClient *redis.Client, basePrefix string) *revocationRedisRepo {
+	return &revocationRedisRepo{redisClient: redisClient, basePrefix: basePrefix}
+}
+
+func (r *revocationRedisRepo) Revoke(ctx context.Context, jti string, ttl time.Duration) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "revocationRedisRepo.Revoke")
+	defer span.Finish()
+
+	if ttl <= 0 {
+		return nil
+	}
+	return r.redisClient.Set(ctx, r.createKey(jti), 1, ttl).Err()
+}
+
+func (r *revocationRedisRepo) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "revocationRedisRepo.IsRevoked")
+	defer span.Finish()
+
+	n, err := r.redisClient.Exists(ctx, r.createKey(jti)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (r *revocationRedisRepo) createKey(value string) string {
+	return fmt.Sprintf("%s: %s", r.basePrefix, value)
+}
+
+// InMemoryRevocationRepo is a RevocationRepository for tests and single
+// instance deployments
+type InMemoryRevocationRepo struct {
+	mu      sync.Mutex
+	revoked map[string]time.Time
+}
+
+func NewInMemoryRevocationRepo() *InMemoryRevocationRepo {
+	return &InMemoryRevocationRepo{revoked: make(map[string]time.Time)}
+}
+
+func (r *InMemoryRevocationRepo) Revoke(ctx context.Context, jti string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for k, exp := range r.revoked {
+		if now.After(exp) {
+			delete(r.revoked, k)
+		}
+	}
+	r.revoked[jti] = now.Add(ttl)
+	return nil
+}
+
+func (r *InMemoryRevocationRepo) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	exp, ok := r.revoked[jti]
+	return ok && time.Now().Before(exp), nil
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/rezaAmiri123/service-user/internal/model"
+)
+
+type RoleRepository interface {
+	EnsureRoles(ctx context.Context, roles map[string][]string) error
+	GetUserRoles(ctx context.Context, userID uint) ([]*model.Role, error)
+	GrantRole(ctx context.Context, userID uint, name string, event *model.AuditEvent) error
+	RevokeRole(ctx context.Context, userID uint, name string, event *model.AuditEvent) error
+}
+
+type ORMRoleRepository struct {
+	db *gorm.DB
+}
+
+func NewORMRoleRepository(db *gorm.DB) *ORMRoleRepository {
+	return &ORMRoleRepository{db: db}
+}
+
+// EnsureRoles creates missing roles and adds missing permissions to them.
+// Permissions granted outside of roles are kept.
+func (repo *ORMRoleRepository) EnsureRoles(ctx context.Context, roles map[string][]string) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "RoleRepository.EnsureRoles")
+	defer span.Finish()
+
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		for name, permissions := range roles {
+			var role model.Role
+			if err := tx.Where(model.Role{Name: name}).FirstOrCreate(&role).Error; err != nil {
+				return err
+			}
+			for _, p := range permissions {
+				var perm model.Permission
+				if err := tx.Where(model.Permission{Name: p}).FirstOrCreate(&perm).Error; err != nil {
+					return err
+				}
+				if err := tx.Model(&role).Association("Permissions").Append(&perm).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// GetUserRoles returns the roles of a user with their permissions
+func (repo *ORMRoleRepository) GetUserRoles(ctx context.Context, userID uint) ([]*model.Role, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "RoleRepository.GetUserRoles")
+	defer span.Finish()
+
+	var roles []*model.Role
+	err := repo.db.Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Preload("Permissions").
+		Order("roles.name").
+		Find(&roles).Error
+	return roles, err
+}
+
+// GrantRole gives a role to a user and stores the audit event of the change
+// in the same transaction, when one is given. It fails with
+// gorm.ErrRecordNotFound when the role does not exist.
+func (repo *ORMRoleRepository) GrantRole(ctx context.Context, userID uint, name string, event *model.AuditEvent) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "RoleRepository.GrantRole")
+	defer span.Finish()
+
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		var role model.Role
+		if err := tx.Where(model.Role{Name: name}).First(&role).Error; err != nil {
+			return err
+		}
+		user := &model.User{Model: gorm.Model{ID: userID}}
+		err := tx.Set("gorm:association_autoupdate", false).
+			Model(user).Association("Roles").Append(&role).Error
+		if err != nil {
+			return err
+		}
+		return recordEvent(tx, event)
+	})
+}
+
+// RevokeRole takes a role away from a user and stores the audit event of
+// the change in the same transaction, when one is given. It fails with
+// gorm.ErrRecordNotFound when the role does not exist.
+func (repo *ORMRoleRepository) RevokeRole(ctx context.Context, userID uint, name string, event *model.AuditEvent) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "RoleRepository.RevokeRole")
+	defer span.Finish()
+
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		var role model.Role
+		if err := tx.Where(model.Role{Name: name}).First(&role).Error; err != nil {
+			return err
+		}
+		user := &model.User{Model: gorm.Model{ID: userID}}
+		if err := tx.Model(user).Association("Roles").Delete(&role).Error; err != nil {
+			return err
+		}
+		return recordEvent(tx, event)
+	})
+}
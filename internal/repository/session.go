@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/rezaAmiri123/service-user/internal/model"
+)
+
+type SessionRepository interface {
+	CreateSession(ctx context.Context, session *model.Session) error
+	ListSessions(ctx context.Context, userID uint) ([]*model.Session, error)
+	TouchSession(ctx context.Context, sessionID, ip string, expiresAt time.Time) error
+	IsSessionActive(ctx context.Context, sessionID string) (bool, error)
+	RevokeSession(ctx context.Context, userID uint, sessionID string) error
+	RevokeOtherSessions(ctx context.Context, userID uint, keepSessionID string) ([]string, error)
+	RevokeUserSessions(ctx context.Context, userID uint) error
+	PruneSessions(ctx context.Context, now time.Time) (int64, error)
+}
+
+// sessionActiveCacheDuration bounds how long a session is cached as active,
+// in case a revocation failed to clear the cache
+const sessionActiveCacheDuration = time.Minute
+
+type ORMSessionRepository struct {
+	db        *gorm.DB
+	cacheRepo SessionCacheRepository
+}
+
+func NewORMSessionRepository(db *gorm.DB, cacheRepo SessionCacheRepository) *ORMSessionRepository {
+	return &ORMSessionRepository{db: db, cacheRepo: cacheRepo}
+}
+
+// CreateSession stores a new session
+func (repo *ORMSessionRepository) CreateSession(ctx context.Context, session *model.Session) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "SessionRepository.CreateSession")
+	defer span.Finish()
+
+	if session.LastSeenAt.IsZero() {
+		session.LastSeenAt = time.Now()
+	}
+	return repo.db.Create(session).Error
+}
+
+// ListSessions returns the active sessions of a user, most recently seen first
+func (repo *ORMSessionRepository) ListSessions(ctx context.Context, userID uint) ([]*model.Session, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "SessionRepository.ListSessions")
+	defer span.Finish()
+
+	var sessions []*model.Session
+	err := repo.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at desc").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// TouchSession records that a session was just used from an IP and extends
+// it to the expiry of its new refresh token
+func (repo *ORMSessionRepository) TouchSession(ctx context.Context, sessionID, ip string, expiresAt time.Time) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "SessionRepository.TouchSession")
+	defer span.Finish()
+
+	fields := map[string]interface{}{"last_seen_at": time.Now(), "expires_at": expiresAt}
+	if ip != "" {
+		fields["ip"] = ip
+	}
+	return repo.db.Model(&model.Session{}).
+		Where("session_id = ?", sessionID).
+		Updates(fields).Error
+}
+
+// IsSessionActive reports whether a session exists, was not revoked and did
+// not expire. It runs for every authenticated call, so active sessions are
+// cached briefly; revocations clear the cache.
+func (repo *ORMSessionRepository) IsSessionActive(ctx context.Context, sessionID string) (bool, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "SessionRepository.IsSessionActive")
+	defer span.Finish()
+
+	if active, err := repo.cacheRepo.IsActive(ctx, sessionID); err == nil && active {
+		return true, nil
+	}
+	now := time.Now()
+	var expiries []time.Time
+	err := repo.db.Model(&model.Session{}).
+		Where("session_id = ? AND revoked_at IS NULL AND expires_at > ?", sessionID, now).
+		Pluck("expires_at", &expiries).Error
+	if err != nil {
+		return false, err
+	}
+	if len(expiries) == 0 {
+		return false, nil
+	}
+	ttl := expiries[0].Sub(now)
+	if ttl > sessionActiveCacheDuration {
+		ttl = sessionActiveCacheDuration
+	}
+	repo.cacheRepo.SetActive(ctx, sessionID, ttl)
+	return true, nil
+}
+
+// RevokeSession revokes a session of a user. It fails with
+// gorm.ErrRecordNotFound when the user has no such active session.
+func (repo *ORMSessionRepository) RevokeSession(ctx context.Context, userID uint, sessionID string) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "SessionRepository.RevokeSession")
+	defer span.Finish()
+
+	res := repo.db.Model(&model.Session{}).
+		Where("user_id = ? AND session_id = ? AND revoked_at IS NULL", userID, sessionID).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return repo.cacheRepo.Delete(ctx, sessionID)
+}
+
+// RevokeOtherSessions revokes every active session of a user except one and
+// returns the ids of the revoked sessions
+func (repo *ORMSessionRepository) RevokeOtherSessions(ctx context.Context, userID uint, keepSessionID string) ([]string, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "SessionRepository.RevokeOtherSessions")
+	defer span.Finish()
+
+	var ids []string
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		scope := tx.Model(&model.Session{}).
+			Where("user_id = ? AND session_id <> ? AND revoked_at IS NULL", userID, keepSessionID)
+		if err := scope.Pluck("session_id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		return tx.Model(&model.Session{}).
+			Where("session_id IN (?)", ids).
+			Update("revoked_at", time.Now()).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, repo.cacheRepo.Delete(ctx, ids...)
+}
+
+// RevokeUserSessions revokes every session of a user
+func (repo *ORMSessionRepository) RevokeUserSessions(ctx context.Context, userID uint) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "SessionRepository.RevokeUserSessions")
+	defer span.Finish()
+
+	var ids []string
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		scope := tx.Model(&model.Session{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID)
+		if err := scope.Pluck("session_id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		return tx.Model(&model.Session{}).
+			Where("session_id IN (?)", ids).
+			Update("revoked_at", time.Now()).Error
+	})
+	if err != nil {
+		return err
+	}
+	return repo.cacheRepo.Delete(ctx, ids...)
+}
+
+// PruneSessions deletes the sessions that expired before now, revoked or
+// not, and returns how many were deleted
+func (repo *ORMSessionRepository) PruneSessions(ctx context.Context, now time.Time) (int64, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "SessionRepository.PruneSessions")
+	defer span.Finish()
+
+	res := repo.db.Unscoped().Where("expires_at <= ?", now).Delete(&model.Session{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/jinzhu/gorm"
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/rezaAmiri123/service-user/internal/model"
+)
+
+// Candidate is a user suggested to follow. Mutual is the number of users
+// followed by the user receiving the suggestion who follow the candidate.
+type Candidate struct {
+	UserID    uint    `json:"user_id"`
+	Mutual    int     `json:"mutual"`
+	Followers int64   `json:"followers"`
+	Score     float64 `json:"score"`
+}
+
+// SuggestionRepository finds users to suggest following. Candidates are
+// never the user, users they follow or asked to follow, users blocked
+// either way, or hidden accounts.
+type SuggestionRepository interface {
+	FriendsOfFriends(ctx context.Context, userID uint, limit int) ([]Candidate, error)
+	Popular(ctx context.Context, userID uint, limit int) ([]Candidate, error)
+}
+
+type ORMSuggestionRepository struct {
+	db *gorm.DB
+}
+
+func NewORMSuggestionRepository(db *gorm.DB) *ORMSuggestionRepository {
+	return &ORMSuggestionRepository{db: db}
+}
+
+// hiddenStatuses are the statuses of accounts never suggested
+var hiddenStatuses = []string{
+	model.UserStatusSuspended,
+	model.UserStatusDeactivated,
+	model.UserStatusPendingDeletion,
+	model.UserStatusDeleted,
+}
+
+// candidateFilter excludes from users the accounts that cannot be
+// suggested to the user given as its five arguments
+const candidateFilter = `users.deleted_at IS NULL AND users.id <> ? AND users.status NOT IN (?)
+	AND NOT EXISTS (SELECT 1 FROM follows f WHERE f.from_user_id = ? AND f.to_user_id = users.id)
+	AND NOT EXISTS (SELECT 1 FROM follow_requests r WHERE r.from_user_id = ? AND r.to_user_id = users.id)
+	AND NOT EXISTS (SELECT 1 FROM blocks b WHERE (b.blocker_id = ? AND b.blocked_id = users.id)
+		OR (b.blocker_id = users.id AND b.blocked_id = ?))`
+
+func candidateFilterArgs(userID uint) []interface{} {
+	return []interface{}{userID, hiddenStatuses, userID, userID, userID, userID}
+}
+
+// FriendsOfFriends finds the users followed by the users a user follows,
+// the most mutual follows first
+func (repo *ORMSuggestionRepository) FriendsOfFriends(ctx context.Context, userID uint, limit int) ([]Candidate, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "SuggestionRepository.FriendsOfFriends")
+	defer span.Finish()
+
+	query := fmt.Sprintf(`SELECT users.id, COUNT(*) AS mutual, users.followers_count
+	FROM follows f1
+	JOIN follows f2 ON f2.from_user_id = f1.to_user_id
+	JOIN users ON users.id = f2.to_user_id
+	WHERE f1.from_user_id = ? AND %s
+	GROUP BY users.id, users.followers_count
+	ORDER BY mutual DESC, users.followers_count DESC, users.id
+	LIMIT ?`, candidateFilter)
+	args := append([]interface{}{userID}, candidateFilterArgs(userID)...)
+	return scanCandidates(repo.db.Raw(query, append(args, limit)...))
+}
+
+// Popular finds the users with the most followers
+func (repo *ORMSuggestionRepository) Popular(ctx context.Context, userID uint, limit int) ([]Candidate, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "SuggestionRepository.Popular")
+	defer span.Finish()
+
+	query := fmt.Sprintf(`SELECT users.id, 0, users.followers_count
+	FROM users
+	WHERE users.followers_count > 0 AND %s
+	ORDER BY users.followers_count DESC, users.id
+	LIMIT ?`, candidateFilter)
+	return scanCandidates(repo.db.Raw(query, append(candidateFilterArgs(userID), limit)...))
+}
+
+func scanCandidates(q *gorm.DB) ([]Candidate, error) {
+	rows, err := q.Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var candidates []Candidate
+	for rows.Next() {
+		var c Candidate
+		if err := rows.Scan(&c.UserID, &c.Mutual, &c.Followers); err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, rows.Err()
+}
+
+// SuggestionCacheRepository keeps the ranked candidates computed for a user
+type SuggestionCacheRepository interface {
+	Get(ctx context.Context, userID uint) ([]Candidate, error)
+	Set(ctx context.Context, userID uint, candidates []Candidate, ttl time.Duration) error
+	Delete(ctx context.Context, userID uint) error
+}
+
+type suggestionRedisRepo struct {
+	redisClient *redis.Client
+	basePrefix  string
+}
+
+func NewSuggestionRedisRepo(redisClient *redis.Client, basePrefix string) *suggestionRedisRepo {
+	return &suggestionRedisRepo{redisClient: redisClient, basePrefix: basePrefix}
+}
+
+// Get returns the cached candidates of a user, nil when there are none
+func (r *suggestionRedisRepo) Get(ctx context.Context, userID uint) ([]Candidate, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "suggestionRedisRepo.Get")
+	defer span.Finish()
+
+	b, err := r.redisClient.Get(ctx, r.createKey(userID)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	candidates := []Candidate{}
+	if err := json.Unmarshal(b, &candidates); err != nil {
+		return nil, err
+	}
+	return candidates, nil
+}
+
+func (r *suggestionRedisRepo) Set(ctx context.Context, userID uint, candidates []Candidate, ttl time.Duration) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "suggestionRedisRepo.Set")
+	defer span.Finish()
+
+	if candidates == nil {
+		candidates = []Candidate{}
+	}
+	b, err := json.Marshal(candidates)
+	if err != nil {
+		return err
+	}
+	return r.redisClient.Set(ctx, r.createKey(userID), b, ttl).Err()
+}
+
+func (r *suggestionRedisRepo) Delete(ctx context.Context, userID uint) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "suggestionRedisRepo.Delete")
+	defer span.Finish()
+
+	return r.redisClient.Del(ctx, r.createKey(userID)).Err()
+}
+
+func (r *suggestionRedisRepo) createKey(userID uint) string {
+	return fmt.Sprintf("%s%d", r.basePrefix, userID)
+}
@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/rezaAmiri123/service-user/internal/model"
+	"github.com/rezaAmiri123/service-user/pkg/grpc_errors"
+)
+
+type TokenRepository interface {
+	CreateRefreshToken(ctx context.Context, token *model.RefreshToken) error
+	GetRefreshToken(ctx context.Context, hash string) (*model.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, old, next *model.RefreshToken) error
+	RevokeTokenFamily(ctx context.Context, familyID string) error
+	RevokeUserTokens(ctx context.Context, userID uint) error
+	CreatePasswordResetToken(ctx context.Context, token *model.PasswordResetToken) error
+	GetPasswordResetToken(ctx context.Context, hash string) (*model.PasswordResetToken, error)
+	UsePasswordResetToken(ctx context.Context, token *model.PasswordResetToken) error
+	ReplaceRecoveryCodes(ctx context.Context, userID uint, codes []*model.RecoveryCode) error
+	UseRecoveryCode(ctx context.Context, userID uint, hash string) error
+}
+
+type ORMTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewORMTokenRepository(db *gorm.DB) *ORMTokenRepository {
+	return &ORMTokenRepository{db: db}
+}
+
+// CreateRefreshToken stores a refresh token
+func (repo *ORMTokenRepository) CreateRefreshToken(ctx context.Context, token *model.RefreshToken) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "TokenRepository.CreateRefreshToken")
+	defer span.Finish()
+
+	return repo.db.Create(token).Error
+}
+
+// GetRefreshToken finds a refresh token from its hash
+func (repo *ORMTokenRepository) GetRefreshToken(ctx context.Context, hash string) (*model.RefreshToken, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "TokenRepository.GetRefreshToken")
+	defer span.Finish()
+
+	var t model.RefreshToken
+	if err := repo.db.Where(model.RefreshToken{TokenHash: hash}).First(&t).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// RotateRefreshToken marks the old token as rotated and stores the next one.
+// It fails with grpc_errors.ErrRefreshTokenReused when the old token was
+// already rotated or revoked, even by a concurrent request.
+func (repo *ORMTokenRepository) RotateRefreshToken(ctx context.Context, old, next *model.RefreshToken) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "TokenRepository.RotateRefreshToken")
+	defer span.Finish()
+
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&model.RefreshToken{}).
+			Where("id = ? AND rotated_at IS NULL AND revoked_at IS NULL", old.ID).
+			Update("rotated_at", time.Now())
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return grpc_errors.ErrRefreshTokenReused
+		}
+		return tx.Create(next).Error
+	})
+}
+
+// RevokeTokenFamily revokes every refresh token of a family
+func (repo *ORMTokenRepository) RevokeTokenFamily(ctx context.Context, familyID string) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "TokenRepository.RevokeTokenFamily")
+	defer span.Finish()
+
+	return repo.db.Model(&model.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeUserTokens revokes every refresh token of a user
+func (repo *ORMTokenRepository) RevokeUserTokens(ctx context.Context, userID uint) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "TokenRepository.RevokeUserTokens")
+	defer span.Finish()
+
+	return repo.db.Model(&model.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// CreatePasswordResetToken stores a password reset token
+func (repo *ORMTokenRepository) CreatePasswordResetToken(ctx context.Context, token *model.PasswordResetToken) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "TokenRepository.CreatePasswordResetToken")
+	defer span.Finish()
+
+	return repo.db.Create(token).Error
+}
+
+// GetPasswordResetToken finds a password reset token from its hash
+func (repo *ORMTokenRepository) GetPasswordResetToken(ctx context.Context, hash string) (*model.PasswordResetToken, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "TokenRepository.GetPasswordResetToken")
+	defer span.Finish()
+
+	var t model.PasswordResetToken
+	if err := repo.db.Where(model.PasswordResetToken{TokenHash: hash}).First(&t).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// UsePasswordResetToken marks a password reset token as used. It fails with
+// grpc_errors.ErrTokenUsed when the token was already used.
+func (repo *ORMTokenRepository) UsePasswordResetToken(ctx context.Context, token *model.PasswordResetToken) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "TokenRepository.UsePasswordResetToken")
+	defer span.Finish()
+
+	res := repo.db.Model(&model.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", token.ID).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return grpc_errors.ErrTokenUsed
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodes deletes the recovery codes of a user and stores new ones
+func (repo *ORMTokenRepository) ReplaceRecoveryCodes(ctx context.Context, userID uint, codes []*model.RecoveryCode) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "TokenRepository.ReplaceRecoveryCodes")
+	defer span.Finish()
+
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		for _, c := range codes {
+			c.UserID = userID
+			if err := tx.Create(c).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// UseRecoveryCode marks an unused recovery code of a user as used. It fails
+// with grpc_errors.ErrTokenUsed when there is no such unused code.
+func (repo *ORMTokenRepository) UseRecoveryCode(ctx context.Context, userID uint, hash string) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "TokenRepository.UseRecoveryCode")
+	defer span.Finish()
+
+	res := repo.db.Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return grpc_errors.ErrTokenUsed
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+
+	"github.com/rezaAmiri123/service-user/internal/model"
+	"github.com/rezaAmiri123/service-user/pkg/grpc_errors"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.AutoMigrate(&model.RefreshToken{}).Error; err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name string
+		// prepare changes the stored token before it is rotated
+		prepare func(repo *ORMTokenRepository, old *model.RefreshToken) error
+		wantErr error
+	}{
+		{
+			name:    "fresh token",
+			prepare: func(repo *ORMTokenRepository, old *model.RefreshToken) error { return nil },
+		},
+		{
+			name: "already rotated",
+			prepare: func(repo *ORMTokenRepository, old *model.RefreshToken) error {
+				next := &model.RefreshToken{UserID: 1, FamilyID: "family", TokenHash: "earlier-next", ExpiresAt: now.Add(time.Hour)}
+				return repo.RotateRefreshToken(context.Background(), old, next)
+			},
+			wantErr: grpc_errors.ErrRefreshTokenReused,
+		},
+		{
+			name: "family revoked",
+			prepare: func(repo *ORMTokenRepository, old *model.RefreshToken) error {
+				return repo.RevokeTokenFamily(context.Background(), old.FamilyID)
+			},
+			wantErr: grpc_errors.ErrRefreshTokenReused,
+		},
+		{
+			name: "user tokens revoked",
+			prepare: func(repo *ORMTokenRepository, old *model.RefreshToken) error {
+				return repo.RevokeUserTokens(context.Background(), old.UserID)
+			},
+			wantErr: grpc_errors.ErrRefreshTokenReused,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := NewORMTokenRepository(newTestDB(t))
+			old := &model.RefreshToken{UserID: 1, FamilyID: "family", TokenHash: "old", ExpiresAt: now.Add(time.Hour)}
+			if err := repo.CreateRefreshToken(ctx, old); err != nil {
+				t.Fatalf("CreateRefreshToken: %v", err)
+			}
+			if err := tt.prepare(repo, old); err != nil {
+				t.Fatalf("prepare: %v", err)
+			}
+
+			next := &model.RefreshToken{UserID: 1, FamilyID: "family", TokenHash: "next", ExpiresAt: now.Add(time.Hour)}
+			err := repo.RotateRefreshToken(ctx, old, next)
+			if err != tt.wantErr {
+				t.Fatalf("RotateRefreshToken = %v, want %v", err, tt.wantErr)
+			}
+			_, getErr := repo.GetRefreshToken(ctx, "next")
+			if stored := getErr == nil; stored != (tt.wantErr == nil) {
+				t.Errorf("next token stored = %v, want %v", stored, tt.wantErr == nil)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			rotated, err := repo.GetRefreshToken(ctx, "old")
+			if err != nil {
+				t.Fatalf("GetRefreshToken: %v", err)
+			}
+			if rotated.RotatedAt == nil {
+				t.Error("old token was not marked rotated")
+			}
+		})
+	}
+}
+
+// TestRotateRefreshTokenConcurrent checks that when the same token is
+// rotated by concurrent requests only one of them gets a new token
+func TestRotateRefreshTokenConcurrent(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	// one connection, so the in-memory database is shared
+	db.DB().SetMaxOpenConns(1)
+	repo := NewORMTokenRepository(db)
+	old := &model.RefreshToken{UserID: 1, FamilyID: "family", TokenHash: "old", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := repo.CreateRefreshToken(ctx, old); err != nil {
+		t.Fatalf("CreateRefreshToken: %v", err)
+	}
+
+	const requests = 8
+	errs := make(chan error, requests)
+	for i := 0; i < requests; i++ {
+		go func(i int) {
+			next := &model.RefreshToken{UserID: 1, FamilyID: "family", TokenHash: fmt.Sprintf("next-%d", i), ExpiresAt: time.Now().Add(time.Hour)}
+			errs <- repo.RotateRefreshToken(ctx, old, next)
+		}(i)
+	}
+	rotated := 0
+	for i := 0; i < requests; i++ {
+		switch err := <-errs; err {
+		case nil:
+			rotated++
+		case grpc_errors.ErrRefreshTokenReused:
+		default:
+			t.Errorf("RotateRefreshToken: %v", err)
+		}
+	}
+	if rotated != 1 {
+		t.Fatalf("token rotated %d times, want once", rotated)
+	}
+}
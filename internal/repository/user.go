@@ -2,6 +2,9 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/jinzhu/gorm"
 	"github.com/opentracing/opentracing-go"
@@ -12,17 +15,43 @@ import (
 
 const (
 	userByIdCacheDuration = 3600
+	// recountBatchSize is the number of users RecountFollows updates at
+	// once
+	recountBatchSize = 500
 )
 
 type UserRepository interface {
 	Create(ctx context.Context, user *model.User) error
-	Update(ctx context.Context, user *model.User) error
+	Update(ctx context.Context, user *model.User, columns ...string) error
+	UseTOTPStep(ctx context.Context, userID uint, step int64) (bool, error)
+	GetTOTPSecret(ctx context.Context, userID uint) (string, error)
 	GetByEmail(ctx context.Context, email string) (*model.User, error)
+	ListByEmail(ctx context.Context, email string) ([]*model.User, error)
 	GetByID(ctx context.Context, id uint) (*model.User, error)
 	GetByUsername(ctx context.Context, username string) (*model.User, error)
+	GetByIDs(ctx context.Context, ids []uint) ([]*model.User, error)
+	GetByUsernames(ctx context.Context, usernames []string) ([]*model.User, error)
+	List(ctx context.Context, filter UserFilter) ([]*model.User, error)
+	PurgeDeleted(ctx context.Context, now time.Time, anonymize bool) ([]uint, error)
+	IsAccountActive(ctx context.Context, userID uint) (bool, error)
+	ReactivateExpired(ctx context.Context, now time.Time) (int64, error)
 	IsFollowing(ctx context.Context, a, b *model.User) (bool, error)
+	FollowingAmong(ctx context.Context, userID uint, ids []uint) (map[uint]bool, error)
+	ListFollowing(ctx context.Context, userID, afterID uint, limit int) ([]*model.User, error)
+	ListFollowers(ctx context.Context, userID, afterID uint, limit int) ([]*model.User, error)
 	Follow(ctx context.Context, a, b *model.User) error
 	Unfollow(ctx context.Context, a, b *model.User) error
+	RecountFollows(ctx context.Context) (int64, error)
+}
+
+// UserFilter selects users for List. Users are ordered by id and only users
+// with an id greater than AfterID are returned.
+type UserFilter struct {
+	// Query matches part of the username or email
+	Query   string
+	Status  string
+	AfterID uint
+	Limit   int
 }
 
 type ORMUserRepository struct {
@@ -42,14 +71,73 @@ func (repo *ORMUserRepository) Create(ctx context.Context, user *model.User) err
 	return repo.db.Create(user).Error
 }
 
-//Update update the user
-func (repo *ORMUserRepository) Update(ctx context.Context, user *model.User) error {
+// Update writes the given columns of the user. Only the columns the caller
+// changed are written, so concurrent updates of other columns are kept;
+// empty values clear a column.
+func (repo *ORMUserRepository) Update(ctx context.Context, user *model.User, columns ...string) error {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "UserRepository.Update")
 	defer span.Finish()
 
+	if len(columns) == 0 {
+		return nil
+	}
+	values, err := columnValues(repo.db, user, columns)
+	if err != nil {
+		return err
+	}
+	err = repo.db.Model(user).Updates(values).Error
+	// invalidated after the write, so a concurrent read cannot cache the
+	// old row again
 	repo.cacheRepo.DeleteByID(ctx, utils.UintToString(user.ID))
+	return err
+}
 
-	return repo.db.Model(user).Update(user).Error
+// columnValues maps the given columns to their values in user
+func columnValues(db *gorm.DB, user *model.User, columns []string) (map[string]interface{}, error) {
+	scope := db.NewScope(user)
+	values := make(map[string]interface{}, len(columns))
+	for _, column := range columns {
+		field, ok := scope.FieldByName(column)
+		if !ok {
+			return nil, fmt.Errorf("unknown user column %q", column)
+		}
+		values[field.DBName] = field.Field.Interface()
+	}
+	return values, nil
+}
+
+// GetTOTPSecret returns the encrypted TOTP secret of a user. It is read from
+// the database as the cache leaves it out.
+func (repo *ORMUserRepository) GetTOTPSecret(ctx context.Context, userID uint) (string, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserRepository.GetTOTPSecret")
+	defer span.Finish()
+
+	var secrets []string
+	err := repo.db.Model(&model.User{}).Where("id = ?", userID).Pluck("totp_secret", &secrets).Error
+	if err != nil {
+		return "", err
+	}
+	if len(secrets) == 0 {
+		return "", gorm.ErrRecordNotFound
+	}
+	return secrets[0], nil
+}
+
+// UseTOTPStep records that the TOTP code of a time step was used. It
+// reports false when a code of that step or a later one was already used,
+// so a code is only ever accepted once, even by concurrent requests.
+func (repo *ORMUserRepository) UseTOTPStep(ctx context.Context, userID uint, step int64) (bool, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserRepository.UseTOTPStep")
+	defer span.Finish()
+
+	res := repo.db.Model(&model.User{}).
+		Where("id = ? AND totp_last_step < ?", userID, step).
+		UpdateColumn("totp_last_step", step)
+	repo.cacheRepo.DeleteByID(ctx, utils.UintToString(userID))
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
 }
 
 // GetByEmail finds a user from email
@@ -67,6 +155,17 @@ func (repo *ORMUserRepository) GetByEmail(ctx context.Context, email string) (*m
 	return &u, nil
 }
 
+// ListByEmail finds every user with an email, for callers that must not
+// pick one of several matches arbitrarily
+func (repo *ORMUserRepository) ListByEmail(ctx context.Context, email string) ([]*model.User, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserRepository.ListByEmail")
+	defer span.Finish()
+
+	var users []*model.User
+	err := repo.db.Where(model.User{Email: email}).Find(&users).Error
+	return users, err
+}
+
 // GetByID finds a user from id
 func (repo *ORMUserRepository) GetByID(ctx context.Context, id uint) (*model.User, error) {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "UserRepository.GetByID")
@@ -99,6 +198,260 @@ func (repo *ORMUserRepository) GetByUsername(ctx context.Context, username strin
 	return &u, nil
 }
 
+// GetByIDs finds the users with the given ids, in any order. Like GetByID
+// it reads through the cache, with one cache lookup and at most one query.
+func (repo *ORMUserRepository) GetByIDs(ctx context.Context, ids []uint) ([]*model.User, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserRepository.GetByIDs")
+	defer span.Finish()
+
+	var users []*model.User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, utils.UintToString(id))
+	}
+	cached, _ := repo.cacheRepo.GetByIDs(ctx, keys)
+	var missing []uint
+	for i, id := range ids {
+		if u := cached[keys[i]]; u != nil {
+			users = append(users, u)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 {
+		return users, nil
+	}
+
+	var loaded []*model.User
+	if err := repo.db.Where("id IN (?)", missing).Find(&loaded).Error; err != nil {
+		return nil, err
+	}
+	toCache := make(map[string]*model.User, len(loaded))
+	for _, u := range loaded {
+		toCache[utils.UintToString(u.ID)] = u
+	}
+	repo.cacheRepo.SetByIDs(ctx, userByIdCacheDuration, toCache)
+	return append(users, loaded...), nil
+}
+
+// GetByUsernames finds the users with the given usernames, in any order
+func (repo *ORMUserRepository) GetByUsernames(ctx context.Context, usernames []string) ([]*model.User, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserRepository.GetByUsernames")
+	defer span.Finish()
+
+	var users []*model.User
+	if len(usernames) == 0 {
+		return users, nil
+	}
+	err := repo.db.Where("username IN (?)", usernames).Find(&users).Error
+	return users, err
+}
+
+// List finds users matching a filter
+func (repo *ORMUserRepository) List(ctx context.Context, filter UserFilter) ([]*model.User, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserRepository.List")
+	defer span.Finish()
+
+	q := repo.db.Where("id > ?", filter.AfterID)
+	if filter.Query != "" {
+		like := "%" + escapeLike(filter.Query) + "%"
+		q = q.Where("username LIKE ? OR email LIKE ?", like, like)
+	}
+	if filter.Status != "" {
+		q = q.Where("status = ?", filter.Status)
+	}
+	var users []*model.User
+	err := q.Order("id").Limit(filter.Limit).Find(&users).Error
+	return users, err
+}
+
+// PurgeDeleted removes the accounts whose deletion is due, with their
+// follows, follow requests, blocks, roles, sessions and tokens, and returns their ids. With anonymize
+// the user rows are kept without personal data instead of being deleted.
+func (repo *ORMUserRepository) PurgeDeleted(ctx context.Context, now time.Time, anonymize bool) ([]uint, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserRepository.PurgeDeleted")
+	defer span.Finish()
+
+	var users []*model.User
+	err := repo.db.Where("status = ? AND status_expires_at <= ?", model.UserStatusPendingDeletion, now).
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	var purged []uint
+	for _, u := range users {
+		var related []uint
+		skipped := false
+		if err := repo.db.Transaction(func(tx *gorm.DB) error {
+			// the user may have logged in and restored the account since
+			// it was loaded
+			err := forUpdate(tx).
+				Where("id = ? AND status = ? AND status_expires_at <= ?", u.ID, model.UserStatusPendingDeletion, now).
+				First(u).Error
+			if gorm.IsRecordNotFoundError(err) {
+				skipped = true
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			related, err = purgeUser(tx, u, anonymize)
+			return err
+		}); err != nil {
+			return purged, err
+		}
+		if skipped {
+			continue
+		}
+		repo.cacheRepo.DeleteByID(ctx, utils.UintToString(u.ID))
+		for _, id := range related {
+			repo.cacheRepo.DeleteByID(ctx, utils.UintToString(id))
+		}
+		purged = append(purged, u.ID)
+	}
+	return purged, nil
+}
+
+// purgeUser removes a user and returns the ids of the users whose follow
+// counters changed
+func purgeUser(tx *gorm.DB, u *model.User, anonymize bool) ([]uint, error) {
+	related, err := removeFollows(tx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := purgeUserData(tx, u, anonymize); err != nil {
+		return nil, err
+	}
+	return related, nil
+}
+
+// removeFollows deletes the follows from and to a user, keeping the
+// counters of the other users right, and returns the ids of those users
+func removeFollows(tx *gorm.DB, userID uint) ([]uint, error) {
+	var followed, followers []uint
+	if err := tx.Table("follows").Where("from_user_id = ?", userID).Pluck("to_user_id", &followed).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Table("follows").Where("to_user_id = ?", userID).Pluck("from_user_id", &followers).Error; err != nil {
+		return nil, err
+	}
+	if len(followed) > 0 {
+		err := tx.Model(&model.User{}).Where("id IN (?)", followed).
+			UpdateColumn("followers_count", gorm.Expr("followers_count - 1")).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(followers) > 0 {
+		err := tx.Model(&model.User{}).Where("id IN (?)", followers).
+			UpdateColumn("following_count", gorm.Expr("following_count - 1")).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Exec("DELETE FROM follows WHERE from_user_id = ? OR to_user_id = ?", userID, userID).Error; err != nil {
+		return nil, err
+	}
+	return append(followed, followers...), nil
+}
+
+func purgeUserData(tx *gorm.DB, u *model.User, anonymize bool) error {
+	if err := tx.Exec("DELETE FROM user_roles WHERE user_id = ?", u.ID).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("blocker_id = ? OR blocked_id = ?", u.ID, u.ID).Delete(&model.Block{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("from_user_id = ? OR to_user_id = ?", u.ID, u.ID).Delete(&model.FollowRequest{}).Error; err != nil {
+		return err
+	}
+	for _, m := range []interface{}{
+		&model.Session{},
+		&model.RefreshToken{},
+		&model.PasswordResetToken{},
+		&model.RecoveryCode{},
+	} {
+		if err := tx.Unscoped().Where("user_id = ?", u.ID).Delete(m).Error; err != nil {
+			return err
+		}
+	}
+	if !anonymize {
+		return tx.Unscoped().Delete(u).Error
+	}
+	u.Anonymize()
+	u.FollowersCount = 0
+	u.FollowingCount = 0
+	values, err := columnValues(tx, u, anonymizedColumns)
+	if err != nil {
+		return err
+	}
+	if err := tx.Model(u).UpdateColumns(values).Error; err != nil {
+		return err
+	}
+	return tx.Delete(u).Error
+}
+
+// anonymizedColumns are the columns User.Anonymize and the purge reset
+var anonymizedColumns = []string{
+	"username", "email", "password", "bio", "image", "verified_at",
+	"pending_email", "totp_secret", "totp_enabled", "totp_last_step",
+	"password_reset_required", "status", "status_reason", "status_expires_at",
+	"followers_count", "following_count",
+}
+
+// forUpdate locks the rows a query selects until the transaction ends;
+// SQLite locks the whole database for a write transaction instead
+func forUpdate(tx *gorm.DB) *gorm.DB {
+	if tx.Dialect().GetName() == "sqlite3" {
+		return tx
+	}
+	return tx.Set("gorm:query_option", "FOR UPDATE")
+}
+
+// IsAccountActive reports whether a user exists and its status is active
+func (repo *ORMUserRepository) IsAccountActive(ctx context.Context, userID uint) (bool, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserRepository.IsAccountActive")
+	defer span.Finish()
+
+	u, err := repo.GetByID(ctx, userID)
+	if gorm.IsRecordNotFoundError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return u.IsActive(), nil
+}
+
+// ReactivateExpired lifts the timed suspensions that expired before now and
+// returns the number of reactivated users
+func (repo *ORMUserRepository) ReactivateExpired(ctx context.Context, now time.Time) (int64, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserRepository.ReactivateExpired")
+	defer span.Finish()
+
+	var ids []uint
+	err := repo.db.Model(&model.User{}).
+		Where("status = ? AND status_expires_at <= ?", model.UserStatusSuspended, now).
+		Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+	res := repo.db.Model(&model.User{}).
+		Where("id IN (?) AND status = ? AND status_expires_at <= ?", ids, model.UserStatusSuspended, now).
+		Updates(map[string]interface{}{
+			"status":            model.UserStatusActive,
+			"status_reason":     "",
+			"status_expires_at": nil,
+		})
+	for _, id := range ids {
+		repo.cacheRepo.DeleteByID(ctx, utils.UintToString(id))
+	}
+	return res.RowsAffected, res.Error
+}
+
 // IsFollowing returns whether user A follows user B or not
 func (repo *ORMUserRepository) IsFollowing(ctx context.Context, a, b *model.User) (bool, error) {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "UserRepository.IsFollowing")
@@ -117,12 +470,79 @@ func (repo *ORMUserRepository) IsFollowing(ctx context.Context, a, b *model.User
 	return count > 0, nil
 }
 
+// FollowingAmong returns the ids among ids of the users a user follows, in
+// one query
+func (repo *ORMUserRepository) FollowingAmong(ctx context.Context, userID uint, ids []uint) (map[uint]bool, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserRepository.FollowingAmong")
+	defer span.Finish()
+
+	if len(ids) == 0 {
+		return map[uint]bool{}, nil
+	}
+	return idSet(repo.db.Table("follows").
+		Where("from_user_id = ? AND to_user_id IN (?)", userID, ids).
+		Select("to_user_id"))
+}
+
+// idSet returns the ids selected by the single column query q
+func idSet(q *gorm.DB) (map[uint]bool, error) {
+	rows, err := q.Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	set := make(map[uint]bool)
+	for rows.Next() {
+		var id uint
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		set[id] = true
+	}
+	return set, rows.Err()
+}
+
+// ListFollowing finds the users a user follows, ordered by id, starting
+// after afterID
+func (repo *ORMUserRepository) ListFollowing(ctx context.Context, userID, afterID uint, limit int) ([]*model.User, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserRepository.ListFollowing")
+	defer span.Finish()
+
+	var users []*model.User
+	err := repo.db.Joins("JOIN follows ON follows.to_user_id = users.id").
+		Where("follows.from_user_id = ? AND users.id > ?", userID, afterID).
+		Order("users.id").
+		Limit(limit).
+		Find(&users).Error
+	return users, err
+}
+
+// ListFollowers finds the users following a user, ordered by id, starting
+// after afterID
+func (repo *ORMUserRepository) ListFollowers(ctx context.Context, userID, afterID uint, limit int) ([]*model.User, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserRepository.ListFollowers")
+	defer span.Finish()
+
+	var users []*model.User
+	err := repo.db.Joins("JOIN follows ON follows.from_user_id = users.id").
+		Where("follows.to_user_id = ? AND users.id > ?", userID, afterID).
+		Order("users.id").
+		Limit(limit).
+		Find(&users).Error
+	return users, err
+}
+
 // Follow create follow relationship to user B from user A
 func (repo *ORMUserRepository) Follow(ctx context.Context, a, b *model.User) error {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "UserRepository.Follow")
 	defer span.Finish()
 
-	return repo.db.Model(a).Association("Follows").Append(b).Error
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		return insertFollow(tx, a.ID, b.ID)
+	})
+	repo.cacheRepo.DeleteByID(ctx, utils.UintToString(a.ID))
+	repo.cacheRepo.DeleteByID(ctx, utils.UintToString(b.ID))
+	return err
 }
 
 // Unfollow delete follow relationship to user B from user a
@@ -130,5 +550,119 @@ func (repo *ORMUserRepository) Unfollow(ctx context.Context, a, b *model.User) e
 	span, ctx := opentracing.StartSpanFromContext(ctx, "UserRepository.Unfollow")
 	defer span.Finish()
 
-	return repo.db.Model(a).Association("Follows").Delete(b).Error
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		return deleteFollow(tx, a.ID, b.ID)
+	})
+	repo.cacheRepo.DeleteByID(ctx, utils.UintToString(a.ID))
+	repo.cacheRepo.DeleteByID(ctx, utils.UintToString(b.ID))
+	return err
+}
+
+// insertFollow adds a follow from user from to user to, unless it exists,
+// with its counters
+func insertFollow(tx *gorm.DB, from, to uint) error {
+	query := fmt.Sprintf(
+		"INSERT INTO follows (from_user_id, to_user_id) SELECT ?, ? %s "+
+			"WHERE NOT EXISTS (SELECT * FROM follows WHERE from_user_id = ? AND to_user_id = ?)",
+		tx.Dialect().SelectFromDummyTable(),
+	)
+	res := tx.Exec(query, from, to, from, to)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return res.Error
+	}
+	return addFollowCounts(tx, from, to, 1)
+}
+
+// deleteFollow deletes the follow from user from to user to, if any, with
+// its counters
+func deleteFollow(tx *gorm.DB, from, to uint) error {
+	res := tx.Exec("DELETE FROM follows WHERE from_user_id = ? AND to_user_id = ?", from, to)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return res.Error
+	}
+	return addFollowCounts(tx, from, to, -1)
+}
+
+// addFollowCounts adds delta to the following count of user from and the
+// followers count of user to. The rows are updated in id order so two
+// users following each other at once cannot deadlock.
+func addFollowCounts(tx *gorm.DB, from, to uint, delta int) error {
+	updates := []struct {
+		id     uint
+		column string
+	}{
+		{from, "following_count"},
+		{to, "followers_count"},
+	}
+	if to < from {
+		updates[0], updates[1] = updates[1], updates[0]
+	}
+	for _, u := range updates {
+		err := tx.Model(&model.User{}).Where("id = ?", u.id).
+			UpdateColumn(u.column, gorm.Expr(u.column+" + ?", delta)).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// followCountsQuery sets the follow counters of users from the follows table
+const followCountsQuery = `UPDATE users SET
+	followers_count = (SELECT COUNT(*) FROM follows WHERE follows.to_user_id = users.id),
+	following_count = (SELECT COUNT(*) FROM follows WHERE follows.from_user_id = users.id)
+	WHERE id IN (?)`
+
+// followCountsDriftQuery finds the users whose follow counters are wrong
+const followCountsDriftQuery = `SELECT id FROM users WHERE
+	followers_count <> (SELECT COUNT(*) FROM follows WHERE follows.to_user_id = users.id) OR
+	following_count <> (SELECT COUNT(*) FROM follows WHERE follows.from_user_id = users.id)`
+
+// RecountFollows recomputes the follow counters that differ from the
+// follows table and returns the number of repaired users
+func (repo *ORMUserRepository) RecountFollows(ctx context.Context) (int64, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UserRepository.RecountFollows")
+	defer span.Finish()
+
+	rows, err := repo.db.Raw(followCountsDriftQuery).Rows()
+	if err != nil {
+		return 0, err
+	}
+	var ids []uint
+	for rows.Next() {
+		var id uint
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var repaired int64
+	for len(ids) > 0 {
+		n := len(ids)
+		if n > recountBatchSize {
+			n = recountBatchSize
+		}
+		batch := ids[:n]
+		ids = ids[n:]
+		res := repo.db.Exec(followCountsQuery, batch)
+		if res.Error != nil {
+			return repaired, res.Error
+		}
+		repaired += res.RowsAffected
+		for _, id := range batch {
+			repo.cacheRepo.DeleteByID(ctx, utils.UintToString(id))
+		}
+	}
+	return repaired, nil
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
 }
@@ -0,0 +1,163 @@
+package suggest
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/rezaAmiri123/service-user/cmd/config"
+	"github.com/rezaAmiri123/service-user/internal/model"
+	"github.com/rezaAmiri123/service-user/internal/repository"
+	"github.com/rezaAmiri123/service-user/pkg/logger"
+)
+
+const (
+	// candidatePool is the number of candidates of each source ranked for
+	// a user
+	candidatePool = 200
+	// mutualWeight and popularityWeight weigh the mutual follows and the
+	// log of the followers of a candidate in its score; ten times as many
+	// followers count about as much as one more mutual follow
+	mutualWeight     = 1.0
+	popularityWeight = 0.43
+)
+
+// Suggestion is a user to follow
+type Suggestion struct {
+	User   *model.User
+	Mutual int
+}
+
+// Service suggests users to follow from the social graph: users followed
+// by the users one follows, then popular users. The ranking of a user is
+// cached until it expires or the user follows or blocks someone.
+type Service struct {
+	repo       repository.SuggestionRepository
+	users      repository.UserRepository
+	cache      repository.SuggestionCacheRepository
+	ttl        time.Duration
+	maxResults int
+	logger     logger.Logger
+}
+
+func NewService(
+	repo repository.SuggestionRepository,
+	users repository.UserRepository,
+	cache repository.SuggestionCacheRepository,
+	cfg config.SuggestConfig,
+	logger logger.Logger,
+) *Service {
+	maxResults := cfg.MaxResults
+	if maxResults <= 0 {
+		maxResults = 50
+	}
+	// a zero TTL would cache suggestions in Redis forever
+	ttl := cfg.CacheTTL * time.Second
+	if ttl <= 0 {
+		ttl = 15 * time.Minute
+	}
+	return &Service{
+		repo:       repo,
+		users:      users,
+		cache:      cache,
+		ttl:        ttl,
+		maxResults: maxResults,
+		logger:     logger,
+	}
+}
+
+// Suggest returns up to limit users for a user to follow, best first
+func (s *Service) Suggest(ctx context.Context, userID uint, limit int) ([]Suggestion, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Suggest.Suggest")
+	defer span.Finish()
+
+	candidates, err := s.cache.Get(ctx, userID)
+	if err != nil {
+		s.logger.Warnf("get cached suggestions of user %d: %v", userID, err)
+	}
+	if candidates == nil {
+		if candidates, err = s.rank(ctx, userID); err != nil {
+			return nil, err
+		}
+		if err := s.cache.Set(ctx, userID, candidates, s.ttl); err != nil {
+			s.logger.Warnf("cache suggestions of user %d: %v", userID, err)
+		}
+	}
+
+	if limit <= 0 || limit > len(candidates) {
+		limit = len(candidates)
+	}
+	candidates = candidates[:limit]
+	ids := make([]uint, 0, len(candidates))
+	for _, c := range candidates {
+		ids = append(ids, c.UserID)
+	}
+	users, err := s.users.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*model.User, len(users))
+	for _, u := range users {
+		byID[u.ID] = u
+	}
+	suggestions := make([]Suggestion, 0, len(candidates))
+	for _, c := range candidates {
+		// the account may have changed since the ranking was cached
+		if u := byID[c.UserID]; u != nil && u.IsVisible() {
+			suggestions = append(suggestions, Suggestion{User: u, Mutual: c.Mutual})
+		}
+	}
+	return suggestions, nil
+}
+
+// Invalidate drops the cached ranking of a user
+func (s *Service) Invalidate(ctx context.Context, userID uint) {
+	if err := s.cache.Delete(ctx, userID); err != nil {
+		s.logger.Warnf("invalidate suggestions of user %d: %v", userID, err)
+	}
+}
+
+// rank scores the candidates of a user and returns the best
+func (s *Service) rank(ctx context.Context, userID uint) ([]repository.Candidate, error) {
+	candidates, err := s.repo.FriendsOfFriends(ctx, userID, candidatePool)
+	if err != nil {
+		return nil, err
+	}
+	// popular users fill in for users with a small graph
+	if len(candidates) < s.maxResults {
+		popular, err := s.repo.Popular(ctx, userID, candidatePool)
+		if err != nil {
+			return nil, err
+		}
+		seen := make(map[uint]bool, len(candidates))
+		for _, c := range candidates {
+			seen[c.UserID] = true
+		}
+		for _, c := range popular {
+			if !seen[c.UserID] {
+				candidates = append(candidates, c)
+			}
+		}
+	}
+
+	for i := range candidates {
+		c := &candidates[i]
+		c.Score = mutualWeight*float64(c.Mutual) + popularityWeight*math.Log1p(float64(c.Followers))
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].UserID < candidates[j].UserID
+	})
+	if len(candidates) > s.maxResults {
+		candidates = candidates[:s.maxResults]
+	}
+	if candidates == nil {
+		candidates = []repository.Candidate{}
+	}
+	return candidates, nil
+}
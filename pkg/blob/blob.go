@@ -0,0 +1,96 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rezaAmiri123/service-user/cmd/config"
+)
+
+// Store keeps blobs under slash separated keys and serves them from a
+// public URL
+type Store interface {
+	Put(ctx context.Context, key, contentType string, r io.Reader) error
+	Delete(ctx context.Context, prefix string) error
+	URL(key string) string
+}
+
+// NewStore returns the store selected by cfg.Blob.Driver. An S3 compatible
+// store can be added by implementing Store.
+func NewStore(cfg *config.Config) (Store, error) {
+	switch cfg.Blob.Driver {
+	case "", "local":
+		return NewLocalStore(cfg.Blob.Dir, cfg.Blob.BaseURL)
+	}
+	return nil, fmt.Errorf("unknown blob driver %q", cfg.Blob.Driver)
+}
+
+// LocalStore keeps blobs as files in a directory; the directory must be
+// served at baseURL, e.g. by the gateway
+type LocalStore struct {
+	dir     string
+	baseURL string
+}
+
+func NewLocalStore(dir, baseURL string) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &LocalStore{dir: dir, baseURL: strings.TrimRight(baseURL, "/")}, nil
+}
+
+// Put writes a blob, replacing an existing one. The file is renamed into
+// place so readers never see a partial blob.
+func (s *LocalStore) Put(ctx context.Context, key, contentType string, r io.Reader) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".upload-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Delete removes every blob whose key starts with prefix followed by a slash
+func (s *LocalStore) Delete(ctx context.Context, prefix string) error {
+	path, err := s.path(prefix)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(path)
+}
+
+func (s *LocalStore) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+// path maps a key to a file inside dir
+func (s *LocalStore) path(key string) (string, error) {
+	clean := filepath.Clean("/" + filepath.FromSlash(key))
+	if clean == string(filepath.Separator) || strings.Contains(key, "..") {
+		return "", errors.New("invalid blob key")
+	}
+	return filepath.Join(s.dir, clean), nil
+}
@@ -12,10 +12,12 @@ import (
 )
 
 var (
-	ErrNotFound         = errors.New("Not found")
-	ErrNoCtxMetaData    = errors.New("No ctx metadata")
-	ErrInvalidSessionId = errors.New("Invalid session id")
-	ErrEmailExists      = errors.New("Email already exists")
+	ErrNotFound           = errors.New("Not found")
+	ErrNoCtxMetaData      = errors.New("No ctx metadata")
+	ErrInvalidSessionId   = errors.New("Invalid session id")
+	ErrEmailExists        = errors.New("Email already exists")
+	ErrRefreshTokenReused = errors.New("Refresh token reused")
+	ErrTokenUsed          = errors.New("Token already used")
 )
 
 // Parse error and get code
@@ -33,6 +35,10 @@ func ParseGRPCErrStatusCode(err error) codes.Code {
 		return codes.AlreadyExists
 	case errors.Is(err, ErrNoCtxMetaData):
 		return codes.Unauthenticated
+	case errors.Is(err, ErrRefreshTokenReused):
+		return codes.Unauthenticated
+	case errors.Is(err, ErrTokenUsed):
+		return codes.InvalidArgument
 	case errors.Is(err, ErrInvalidSessionId):
 		return codes.PermissionDenied
 	case strings.Contains(err.Error(), "validate"):
@@ -0,0 +1,100 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rezaAmiri123/service-user/cmd/config"
+	"github.com/rezaAmiri123/service-user/pkg/logger"
+)
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers emails
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// NewSender returns the sender selected by cfg.Mail.Driver. The driver must
+// be set, so a missing config never silently logs emails.
+func NewSender(cfg *config.Config, logger logger.Logger) (Sender, error) {
+	switch cfg.Mail.Driver {
+	case "":
+		return nil, errors.New("mail driver is not configured")
+	case "log":
+		return NewLogSender(cfg.Mail.From, logger), nil
+	case "file":
+		return NewFileSender(cfg.Mail.From, cfg.Mail.Dir)
+	}
+	return nil, fmt.Errorf("unknown mail driver %q", cfg.Mail.Driver)
+}
+
+// secretParam matches the token and code query parameters of the links in
+// emails
+var secretParam = regexp.MustCompile(`([?&](?:token|code)=)[^&\s]+`)
+
+// LogSender writes emails to the application log, for local development.
+// Tokens and codes in links are redacted, as logs are widely readable.
+type LogSender struct {
+	from   string
+	logger logger.Logger
+}
+
+func NewLogSender(from string, logger logger.Logger) *LogSender {
+	return &LogSender{from: from, logger: logger}
+}
+
+func (s *LogSender) Send(ctx context.Context, msg *Message) error {
+	body := secretParam.ReplaceAllString(msg.Body, "${1}REDACTED")
+	s.logger.Infof("Mail From: %s, To: %s, Subject: %s, Body: %s", s.from, msg.To, msg.Subject, body)
+	return nil
+}
+
+// FileSender writes every email to its own .eml file in a directory, for
+// local development
+type FileSender struct {
+	from string
+	dir  string
+}
+
+func NewFileSender(from, dir string) (*FileSender, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &FileSender{from: from, dir: dir}, nil
+}
+
+func (s *FileSender) Send(ctx context.Context, msg *Message) error {
+	now := time.Now()
+	content := strings.Join([]string{
+		"From: " + s.from,
+		"To: " + msg.To,
+		"Subject: " + msg.Subject,
+		"Date: " + now.Format(time.RFC1123Z),
+		"",
+		msg.Body,
+	}, "\r\n")
+	name := fmt.Sprintf("%d-%s.eml", now.UnixNano(), sanitize(msg.To))
+	return ioutil.WriteFile(filepath.Join(s.dir, name), []byte(content), 0600)
+}
+
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == os.PathSeparator {
+			return '_'
+		}
+		return r
+	}, s)
+}
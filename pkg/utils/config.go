@@ -15,4 +15,9 @@ func GetConfigPath(configPath string) string {
 func UintToString(n uint) string{
 	return strconv.FormatUint(uint64(n), 10)
 
-}
\ No newline at end of file
+}
+
+func StringToUint(s string) (uint, error) {
+	n, err := strconv.ParseUint(s, 10, 64)
+	return uint(n), err
+}